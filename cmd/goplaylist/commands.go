@@ -0,0 +1,774 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/masch/goplaylist/internal/playlist"
+)
+
+// commandRunFunc runs a command with the arguments given after the command name.
+type commandRunFunc func(args []string, newPlaylister newPlaylisterFunc, playlistOutput writer) error
+
+// command represents a subcommand of the command line.
+type command struct {
+	// name is the name used to invoke the command.
+	name string
+
+	// arguments describes the arguments supported after the flags.
+	arguments string
+
+	// description is the short documentation of the command.
+	description string
+
+	// run runs the command.
+	run commandRunFunc
+}
+
+// commands returns the commands supported by the command line.
+func commands() []command {
+	return []command{
+		{
+			name:        "next",
+			description: "List the next files reserving them until they are acknowledged by the ack command",
+			run:         runFileList("next", reserveNextFiles),
+		},
+		{
+			name:        "peek",
+			description: "List the next files without saving them as listed",
+			run:         runFileList("peek", peekNextFiles),
+		},
+		{
+			name:        "ack",
+			arguments:   "[file...]",
+			description: "Acknowledge the files reserved by the next command, every file reserved if none is given",
+			run:         runFileList("ack", ackFiles),
+		},
+		{
+			name:        "list",
+			description: "List every file of the path",
+			run:         runFileList("list", listFiles),
+		},
+		{
+			name:        "history",
+			description: "List the files already listed of the path",
+			run:         runFileList("history", historyFiles),
+		},
+		{
+			name:        "status",
+			description: "Print the last file listed, its index, the remaining files and the progress of the path",
+			run:         StatusFromPath,
+		},
+		{
+			name:        "back",
+			description: "Move back the last file listed so the next files are listed again",
+			run:         runNoOutput(RewindFromPath),
+		},
+		{
+			name:        "seek",
+			arguments:   "<file|index>",
+			description: "Move the last file listed so the next files start from the file or index given",
+			run:         runNoOutput(SeekFromPath),
+		},
+		{
+			name:        "reset",
+			description: "Remove the state of the path so the next files start from the first one",
+			run:         runNoOutput(ResetFromPath),
+		},
+		{
+			name:        "help",
+			arguments:   "[command]",
+			description: "Print the usage documentation of the command given",
+			run:         runHelp,
+		},
+	}
+}
+
+// findCommand returns the command of the name given and whether it was found.
+func findCommand(name string) (command, bool) {
+	for _, cmd := range commands() {
+		if cmd.name == name {
+			return cmd, true
+		}
+	}
+
+	return command{}, false
+}
+
+// printUsage prints the usage documentation of the command line listing the commands supported.
+func printUsage() {
+	fmt.Fprintf(usageOutput, "Usage: goplaylist <command> [flags] [arguments]\n\nCommands:\n")
+
+	for _, cmd := range commands() {
+		fmt.Fprintf(usageOutput, "  %-8s %s\n", cmd.name, cmd.description)
+	}
+
+	fmt.Fprintf(usageOutput, "\nRun \"goplaylist help <command>\" for more information about a command.\n"+
+		"Running goplaylist with flags only is an alias of \"goplaylist next -auto_ack\".\n")
+}
+
+// newFlagSet returns the flag set of the command name given which prints the command usage documentation.
+// If the name is empty, it returns the flag set of the flags only invocation.
+func newFlagSet(name string) *flag.FlagSet {
+	if name == "" {
+		flags := flag.NewFlagSet("goplaylist", flag.ContinueOnError)
+		flags.SetOutput(usageOutput)
+
+		return flags
+	}
+
+	cmd, _ := findCommand(name)
+
+	flags := flag.NewFlagSet("goplaylist "+name, flag.ContinueOnError)
+	flags.SetOutput(usageOutput)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: goplaylist %s [flags] %s\n\n%s\n\nFlags:\n", name, cmd.arguments, cmd.description)
+		flags.PrintDefaults()
+	}
+
+	return flags
+}
+
+// fileListing contains the file list got from the command line.
+type fileListing struct {
+	// files are the files got.
+	files []string
+
+	// sorted returns every file of the path sorted, so the position of the files got can be found.
+	// It is nil when the files got don't belong to a sorted file list.
+	sorted func() ([]string, error)
+}
+
+// fileListFunc gets a file list from the command line parsing the arguments given with the flag set given.
+type fileListFunc func(flags *flag.FlagSet, args []string, newPlaylister newPlaylisterFunc) (fileListing, error)
+
+// runFileList returns a command run function which prints the file list returned by the function given
+// following the output flags. The output flags are defined on the flag set of the command name given.
+func runFileList(name string, fileListFunc fileListFunc) commandRunFunc {
+	return func(args []string, newPlaylister newPlaylisterFunc, playlistOutput writer) error {
+		var output outputFlags
+
+		flags := newFlagSet(name)
+		output.register(flags)
+
+		listing, err := fileListFunc(flags, args, newPlaylister)
+		if err != nil {
+			return err
+		}
+
+		return output.write(listing, playlistOutput)
+	}
+}
+
+// runNoOutput returns a command run function which prints nothing.
+func runNoOutput(runFunc func(args []string, newPlaylister newPlaylisterFunc) error) commandRunFunc {
+	return func(args []string, newPlaylister newPlaylisterFunc, _ writer) error {
+		return runFunc(args, newPlaylister)
+	}
+}
+
+// runGetNextFiles runs the flags only invocation which gets the next files and saves them as listed directly.
+func runGetNextFiles(args []string, newPlaylister newPlaylisterFunc, playlistOutput writer) error {
+	return runFileList("", getNextFilesSaved)(args, newPlaylister, playlistOutput)
+}
+
+// runHelp prints the usage documentation of the command given as argument
+// or the command line usage documentation if there is no command given.
+func runHelp(args []string, newPlaylister newPlaylisterFunc, playlistOutput writer) error {
+	if len(args) == 0 {
+		printUsage()
+		return nil
+	}
+
+	cmd, found := findCommand(args[0])
+	if !found || cmd.name == "help" {
+		printUsage()
+		return fmt.Errorf("%w: %s", errUnknownCommand, args[0])
+	}
+
+	// The help flag prints the command usage documentation
+	return cmd.run([]string{"-h"}, newPlaylister, playlistOutput)
+}
+
+// GetNextFilesFromPath get next files list from the command line using command line flags.
+// If there was an error parsing the flags arguments, it prints the usage documentation on stderr.
+// The state path is taken from the state flag, the GOPLAYLIST_STATE environment variable or the default one
+// in that order of precedence.
+func GetNextFilesFromPath(args []string, newPlaylister newPlaylisterFunc) ([]string, error) {
+	listing, err := getNextFilesSaved(newFlagSet(""), args, newPlaylister)
+
+	return listing.files, err
+}
+
+// getNextFilesSaved get next files list from the command line parsing the arguments with the flag set given
+// saving them as listed directly.
+func getNextFilesSaved(flags *flag.FlagSet, args []string, newPlaylister newPlaylisterFunc) (fileListing, error) {
+	return getNextFiles(flags, args, newPlaylister, nextModeSave)
+}
+
+// ReserveNextFilesFromPath get next files list from the command line using command line flags
+// as GetNextFilesFromPath does, but the files are reserved until they are acknowledged by the ack subcommand.
+// The files are saved as listed directly when the auto_ack flag is given.
+func ReserveNextFilesFromPath(args []string, newPlaylister newPlaylisterFunc) ([]string, error) {
+	listing, err := reserveNextFiles(newFlagSet("next"), args, newPlaylister)
+
+	return listing.files, err
+}
+
+// reserveNextFiles get next files list from the command line parsing the arguments with the flag set given
+// reserving them until they are acknowledged.
+func reserveNextFiles(flags *flag.FlagSet, args []string, newPlaylister newPlaylisterFunc) (fileListing, error) {
+	return getNextFiles(flags, args, newPlaylister, nextModeReserve)
+}
+
+// PeekNextFilesFromPath get next files list from the command line using command line flags
+// as GetNextFilesFromPath does, but the files are not saved as listed.
+func PeekNextFilesFromPath(args []string, newPlaylister newPlaylisterFunc) ([]string, error) {
+	listing, err := peekNextFiles(newFlagSet("peek"), args, newPlaylister)
+
+	return listing.files, err
+}
+
+// peekNextFiles get next files list from the command line parsing the arguments with the flag set given
+// without saving them.
+func peekNextFiles(flags *flag.FlagSet, args []string, newPlaylister newPlaylisterFunc) (fileListing, error) {
+	return getNextFiles(flags, args, newPlaylister, nextModePeek)
+}
+
+// nextMode represents how the next files listed are saved.
+type nextMode uint
+
+const (
+	// nextModeSave represents the next files saved as listed directly.
+	nextModeSave nextMode = iota
+
+	// nextModeReserve represents the next files reserved until they are acknowledged.
+	nextModeReserve
+
+	// nextModePeek represents the next files not saved.
+	nextModePeek
+)
+
+// getNextFiles get next files list from the command line using the flag set given
+// saving them following the mode given.
+func getNextFiles(
+	flags *flag.FlagSet, args []string, newPlaylister newPlaylisterFunc, mode nextMode) (fileListing, error) {
+	// parse flags values from command line
+	var (
+		source     sourceFlags
+		autoAck    = new(bool)
+		pendingTTL = new(time.Duration)
+		dryRun     = new(bool)
+	)
+
+	source.register(flags)
+	countFiles := flags.Int("count", 0, "Specify file count to load from path")
+	onEndRaw := flags.String("on_end", "stop",
+		"Specify the behavior when there are no more files to list: stop, loop or error are supported")
+
+	if mode != nextModePeek {
+		dryRun = flags.Bool("dry_run", false, "List the next files without saving them as listed")
+	}
+
+	if mode == nextModeReserve {
+		autoAck = flags.Bool("auto_ack", false, "Save the next files as listed without waiting for the acknowledgement")
+		pendingTTL = flags.Duration("pending_ttl", 0,
+			"Specify the duration after which the reservation of the files not acknowledged is cancelled, "+
+				"so they are reserved again. Zero means they never expire")
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return fileListing{}, err
+	}
+
+	if source.sortModeRaw == "" && source.sortKeyRegex == "" {
+		flags.Usage()
+		return fileListing{}, errSortModeIsEmpty
+	}
+
+	if source.path == "" {
+		flags.Usage()
+		return fileListing{}, errPathOriginIsEmpty
+	}
+
+	if *countFiles == 0 {
+		flags.Usage()
+		return fileListing{}, errCountFilesIsEmpty
+	}
+
+	if source.extensions == nil {
+		flags.Usage()
+		return fileListing{}, errFilterExtensionsAreEmpty
+	}
+
+	sortMode, err := source.sortOrder()
+	if err != nil {
+		return fileListing{}, err
+	}
+
+	onEnd, err := parseEndMode(*onEndRaw)
+	if err != nil {
+		return fileListing{}, err
+	}
+
+	playlistClient, err := newPlaylister(playlistConfig{
+		statePath:  source.statePath,
+		onEnd:      onEnd,
+		pendingTTL: *pendingTTL,
+	})
+	if err != nil {
+		return fileListing{}, err
+	}
+
+	getNextFiles := playlistClient.GetNextFilesFromPath
+
+	switch {
+	case *dryRun, mode == nextModePeek:
+		getNextFiles = playlistClient.PeekNextFilesFromPath
+	case mode == nextModeReserve && !*autoAck:
+		getNextFiles = playlistClient.ReserveNextFilesFromPath
+	}
+
+	fileList, err := getNextFiles(source.path, *countFiles, source.extensions, sortMode)
+	if err != nil {
+		return fileListing{}, err
+	}
+
+	return fileListing{files: fileList, sorted: func() ([]string, error) {
+		return playlistClient.ListFilesFromPath(source.path, source.extensions, sortMode)
+	}}, nil
+}
+
+// AckFilesFromPath acknowledges the files reserved by the next subcommand using command line flags.
+// The files acknowledged are given as arguments after the flags, acknowledging a file also acknowledges
+// the files reserved before it. If there are no files given, every file reserved is acknowledged.
+func AckFilesFromPath(args []string, newPlaylister newPlaylisterFunc) ([]string, error) {
+	listing, err := ackFiles(newFlagSet("ack"), args, newPlaylister)
+
+	return listing.files, err
+}
+
+// ackFiles acknowledges the files reserved parsing the arguments with the flag set given.
+func ackFiles(flags *flag.FlagSet, args []string, newPlaylister newPlaylisterFunc) (fileListing, error) {
+	path := flags.String("path", "", "Specify path of the files reserved")
+	statePath := stateFlag(flags)
+
+	if err := flags.Parse(args); err != nil {
+		return fileListing{}, err
+	}
+
+	if *path == "" {
+		flags.Usage()
+		return fileListing{}, errPathOriginIsEmpty
+	}
+
+	playlistClient, err := newPlaylister(playlistConfig{statePath: *statePath})
+	if err != nil {
+		return fileListing{}, err
+	}
+
+	fileList, err := playlistClient.AckFilesFromPath(*path, flags.Args())
+
+	return fileListing{files: fileList}, err
+}
+
+// RewindFromPath moves back the last file listed of a path using command line flags,
+// so the next execution lists again the files moved back.
+func RewindFromPath(args []string, newPlaylister newPlaylisterFunc) error {
+	var source sourceFlags
+
+	flags := newFlagSet("back")
+	source.register(flags)
+	count := flags.Int("n", 1, "Specify file count to move back")
+
+	sortMode, err := source.parse(flags, args)
+	if err != nil {
+		return err
+	}
+
+	if *count < 1 {
+		flags.Usage()
+		return errCountIsNotPositive
+	}
+
+	playlistClient, err := newPlaylister(playlistConfig{statePath: source.statePath})
+	if err != nil {
+		return err
+	}
+
+	return playlistClient.RewindFromPath(source.path, *count, source.extensions, sortMode)
+}
+
+// SeekFromPath moves the last file listed of a path using command line flags, so the next execution
+// lists from the file given as argument after the flags. The argument can also be the file index
+// on the file list starting from 1.
+func SeekFromPath(args []string, newPlaylister newPlaylisterFunc) error {
+	var source sourceFlags
+
+	flags := newFlagSet("seek")
+	source.register(flags)
+
+	sortMode, err := source.parse(flags, args)
+	if err != nil {
+		return err
+	}
+
+	if flags.NArg() != 1 || flags.Arg(0) == "" {
+		flags.Usage()
+		return errSeekTargetIsEmpty
+	}
+
+	playlistClient, err := newPlaylister(playlistConfig{statePath: source.statePath})
+	if err != nil {
+		return err
+	}
+
+	if index, err := strconv.Atoi(flags.Arg(0)); err == nil {
+		return playlistClient.SeekIndexFromPath(source.path, index, source.extensions, sortMode)
+	}
+
+	return playlistClient.SeekFileFromPath(source.path, flags.Arg(0), source.extensions, sortMode)
+}
+
+// ResetFromPath removes the state of a path using command line flags,
+// so the next execution lists from the first file.
+func ResetFromPath(args []string, newPlaylister newPlaylisterFunc) error {
+	flags := newFlagSet("reset")
+	path := flags.String("path", "", "Specify path to reset")
+	statePath := stateFlag(flags)
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *path == "" {
+		flags.Usage()
+		return errPathOriginIsEmpty
+	}
+
+	playlistClient, err := newPlaylister(playlistConfig{statePath: *statePath})
+	if err != nil {
+		return err
+	}
+
+	return playlistClient.ResetFromPath(*path)
+}
+
+// ListFilesFromPath get every file of a path using command line flags.
+func ListFilesFromPath(args []string, newPlaylister newPlaylisterFunc) ([]string, error) {
+	listing, err := listFiles(newFlagSet("list"), args, newPlaylister)
+
+	return listing.files, err
+}
+
+// listFiles get every file of a path parsing the arguments with the flag set given.
+func listFiles(flags *flag.FlagSet, args []string, newPlaylister newPlaylisterFunc) (fileListing, error) {
+	var source sourceFlags
+
+	source.register(flags)
+
+	sortMode, err := source.parse(flags, args)
+	if err != nil {
+		return fileListing{}, err
+	}
+
+	playlistClient, err := newPlaylister(playlistConfig{statePath: source.statePath})
+	if err != nil {
+		return fileListing{}, err
+	}
+
+	fileList, err := playlistClient.ListFilesFromPath(source.path, source.extensions, sortMode)
+
+	return fileListing{files: fileList, sorted: func() ([]string, error) {
+		return fileList, nil
+	}}, err
+}
+
+// HistoryFromPath get the files already listed of a path using command line flags.
+func HistoryFromPath(args []string, newPlaylister newPlaylisterFunc) ([]string, error) {
+	listing, err := historyFiles(newFlagSet("history"), args, newPlaylister)
+
+	return listing.files, err
+}
+
+// historyFiles get the files already listed of a path parsing the arguments with the flag set given.
+func historyFiles(flags *flag.FlagSet, args []string, newPlaylister newPlaylisterFunc) (fileListing, error) {
+	var source sourceFlags
+
+	source.register(flags)
+
+	sortMode, err := source.parse(flags, args)
+	if err != nil {
+		return fileListing{}, err
+	}
+
+	playlistClient, err := newPlaylister(playlistConfig{statePath: source.statePath})
+	if err != nil {
+		return fileListing{}, err
+	}
+
+	fileList, err := playlistClient.HistoryFromPath(source.path, source.extensions, sortMode)
+
+	return fileListing{files: fileList, sorted: func() ([]string, error) {
+		return playlistClient.ListFilesFromPath(source.path, source.extensions, sortMode)
+	}}, err
+}
+
+// StatusFromPath prints on the writer given the progress through the file list of a path
+// using command line flags. The progress is printed as JSON when the json flag is given.
+func StatusFromPath(args []string, newPlaylister newPlaylisterFunc, playlistOutput writer) error {
+	var source sourceFlags
+
+	flags := newFlagSet("status")
+	source.register(flags)
+	jsonOutput := flags.Bool("json", false, "Print the status as JSON")
+
+	sortMode, err := source.parse(flags, args)
+	if err != nil {
+		return err
+	}
+
+	playlistClient, err := newPlaylister(playlistConfig{statePath: source.statePath})
+	if err != nil {
+		return err
+	}
+
+	status, err := playlistClient.StatusFromPath(source.path, source.extensions, sortMode)
+	if err != nil {
+		return err
+	}
+
+	var output string
+
+	if *jsonOutput {
+		content, err := json.Marshal(status)
+		if err != nil {
+			return err
+		}
+
+		output = string(content) + "\n"
+	} else {
+		output = fmt.Sprintf("last: %s\nindex: %d/%d\nremaining: %d\npending: %d\nprogress: %.1f%%\n",
+			status.Last, status.Index, status.Total, status.Remaining, status.Pending, status.Progress)
+	}
+
+	if _, err := playlistOutput.WriteString(output); err != nil {
+		return err
+	}
+
+	return playlistOutput.Flush()
+}
+
+// sourceFlags contains the flags values which identify the file list of a path and its state.
+type sourceFlags struct {
+	sortModeRaw     string
+	sortKeyRegex    string
+	sortKeyTypesRaw string
+	path            string
+	extensions      arrayFlags
+	statePath       string
+}
+
+// register defines the source flags on the flag set given.
+func (f *sourceFlags) register(flags *flag.FlagSet) {
+	flags.StringVar(&f.sortModeRaw, "sort_mode", "",
+		"Specify sort mode to list the files: name, natural, mtime, birthtime, timestamp_creation (alias of mtime), "+
+			"dir, shuffle, episode, regex, tags, interleave, size or duration are supported. Several modes followed by "+
+			":asc or :desc can be combined by commas, such as mtime:desc,name:asc. Defaults to regex when "+
+			"-sort_key_regex is given")
+	flags.StringVar(&f.sortKeyRegex, "sort_key_regex", "",
+		"Specify the regular expression of the regex sort mode. The values captured on the file names by its named "+
+			"groups are compared in order")
+	flags.StringVar(&f.sortKeyTypesRaw, "sort_key_types", "",
+		"Specify the comma separated types of the -sort_key_regex named groups in order: string, number or date "+
+			"are supported. The groups without type are compared as strings")
+	flags.StringVar(&f.path, "path", "", "Specify path to load file list")
+	flags.Var(&f.extensions, "extension",
+		"Specify file filter extension. Multiple extensions are supported by adding several -extension entry")
+	flags.StringVar(&f.statePath, "state", os.Getenv(_stateEnvironmentVariable), _stateFlagUsage)
+}
+
+// parse parses the arguments given with the flag set given, validates the source flags and returns the sort mode.
+// If there was an error, it prints the usage documentation on stderr.
+func (f *sourceFlags) parse(flags *flag.FlagSet, args []string) (playlist.SortOrder, error) {
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if f.sortModeRaw == "" && f.sortKeyRegex == "" {
+		flags.Usage()
+		return nil, errSortModeIsEmpty
+	}
+
+	if f.path == "" {
+		flags.Usage()
+		return nil, errPathOriginIsEmpty
+	}
+
+	if f.extensions == nil {
+		flags.Usage()
+		return nil, errFilterExtensionsAreEmpty
+	}
+
+	return f.sortOrder()
+}
+
+// sortOrder returns the sort order of the sort flags. If the sort mode is empty, the regex sort mode is used.
+func (f *sourceFlags) sortOrder() (playlist.SortOrder, error) {
+	var keyRegexp *playlist.SortKeyRegexp
+
+	if f.sortKeyRegex != "" {
+		keyTypes, err := parseSortKeyTypes(f.sortKeyTypesRaw)
+		if err != nil {
+			return nil, err
+		}
+
+		keyRegexp, err = playlist.NewSortKeyRegexp(f.sortKeyRegex, keyTypes...)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sortModeRaw := f.sortModeRaw
+	if sortModeRaw == "" {
+		sortModeRaw = "regex"
+	}
+
+	return parseSortMode(sortModeRaw, keyRegexp)
+}
+
+// stateFlag defines the state flag on the flag set given.
+// Its default value is taken from the GOPLAYLIST_STATE environment variable.
+func stateFlag(flags *flag.FlagSet) *string {
+	return flags.String("state", os.Getenv(_stateEnvironmentVariable), _stateFlagUsage)
+}
+
+// parseSortMode returns the sort order of the sort mode flag value given. The value is either a sort mode
+// or a sort specification of comma separated sort modes followed by ":asc" or ":desc", such as "mtime:desc,name:asc".
+// A single ascendant sort mode is returned as a file sort mode. The regex sort mode uses the sort key regexp given,
+// which is rejected when there is no regex sort mode.
+func parseSortMode(sortModeRaw string, keyRegexp *playlist.SortKeyRegexp) (playlist.SortOrder, error) {
+	var (
+		spec       playlist.SortSpec
+		usesRegexp bool
+	)
+
+	for _, keyRaw := range strings.Split(sortModeRaw, ",") {
+		modeRaw, directionRaw := keyRaw, ""
+		if separator := strings.LastIndex(keyRaw, ":"); separator >= 0 {
+			modeRaw, directionRaw = keyRaw[:separator], keyRaw[separator+1:]
+		}
+
+		mode, err := parseFileSortMode(modeRaw)
+		if err != nil {
+			return nil, err
+		}
+
+		key := playlist.SortKey{Mode: mode}
+
+		if mode == playlist.FileSortModeRegexp {
+			if keyRegexp == nil {
+				return nil, errSortKeyRegexIsEmpty
+			}
+
+			key.Regexp, usesRegexp = keyRegexp, true
+		}
+
+		switch directionRaw {
+		case "", "asc":
+		case "desc":
+			key.Descending = true
+		default:
+			return nil, fmt.Errorf("%w: %s", errUnknownSortDirection, keyRaw)
+		}
+
+		spec = append(spec, key)
+	}
+
+	if keyRegexp != nil && !usesRegexp {
+		return nil, errSortKeyRegexIsUnused
+	}
+
+	if len(spec) == 1 && !spec[0].Descending && spec[0].Regexp == nil {
+		return spec[0].Mode, nil
+	}
+
+	return spec, nil
+}
+
+// parseFileSortMode returns the file sort mode of the sort mode name given.
+func parseFileSortMode(sortModeRaw string) (playlist.FileSortMode, error) {
+	switch sortModeRaw {
+	case "name":
+		return playlist.FileSortModeFileNameAsc, nil
+	case "timestamp_creation":
+		return playlist.FileSortModeTimestampCreationAsc, nil
+	case "natural":
+		return playlist.FileSortModeNaturalAsc, nil
+	case "mtime":
+		return playlist.FileSortModeModTimeAsc, nil
+	case "birthtime":
+		return playlist.FileSortModeBirthTimeAsc, nil
+	case "dir":
+		return playlist.FileSortModeDirectoryAsc, nil
+	case "shuffle":
+		return playlist.FileSortModeShuffle, nil
+	case "episode":
+		return playlist.FileSortModeEpisodeAsc, nil
+	case "regex":
+		return playlist.FileSortModeRegexp, nil
+	case "tags":
+		return playlist.FileSortModeTagsAsc, nil
+	case "interleave":
+		return playlist.FileSortModeInterleave, nil
+	case "size":
+		return playlist.FileSortModeSizeAsc, nil
+	case "duration":
+		return playlist.FileSortModeDurationAsc, nil
+	default:
+		return 0, fmt.Errorf("%w: %s", errUnknownFileSortMode, sortModeRaw)
+	}
+}
+
+// parseSortKeyTypes returns the sort key types of the comma separated sort key types flag value given.
+func parseSortKeyTypes(sortKeyTypesRaw string) ([]playlist.SortKeyType, error) {
+	if sortKeyTypesRaw == "" {
+		return nil, nil
+	}
+
+	var keyTypes []playlist.SortKeyType
+
+	for _, keyTypeRaw := range strings.Split(sortKeyTypesRaw, ",") {
+		switch keyTypeRaw {
+		case "string":
+			keyTypes = append(keyTypes, playlist.SortKeyTypeString)
+		case "number":
+			keyTypes = append(keyTypes, playlist.SortKeyTypeNumber)
+		case "date":
+			keyTypes = append(keyTypes, playlist.SortKeyTypeDate)
+		default:
+			return nil, fmt.Errorf("%w: %s", errUnknownSortKeyType, keyTypeRaw)
+		}
+	}
+
+	return keyTypes, nil
+}
+
+// parseEndMode returns the end mode of the on_end flag value given.
+func parseEndMode(onEndRaw string) (playlist.EndMode, error) {
+	switch onEndRaw {
+	case "stop":
+		return playlist.EndModeStop, nil
+	case "loop":
+		return playlist.EndModeLoop, nil
+	case "error":
+		return playlist.EndModeError, nil
+	default:
+		return 0, fmt.Errorf("%w: %s", errUnknownEndMode, onEndRaw)
+	}
+}
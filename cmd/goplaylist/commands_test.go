@@ -0,0 +1,212 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/masch/goplaylist/internal/playlist"
+)
+
+func TestRunCommands(t *testing.T) { //nolint // function tool large because of BDD mechanism
+	sortMode := playlist.FileSortMode(playlist.FileSortModeFileNameAsc)
+	source := []string{"-sort_mode", "name", "-path", "2", "-extension", ".ext"}
+	next := append([]string{"-count", "1"}, source...)
+
+	tt := []struct {
+		name     string
+		args     []string
+		method   string
+		margs    []interface{}
+		fileList []string
+		output   string
+		err      error
+		exitCode int
+	}{
+		{
+			name:     "OK_flags_only_alias",
+			args:     next,
+			method:   "GetNextFilesFromPath",
+			margs:    []interface{}{"2", 1, []string{".ext"}, sortMode},
+			fileList: []string{"file_1"},
+			output:   `"file_1" `,
+		},
+		{
+			name:     "OK_next",
+			args:     append([]string{"next"}, next...),
+			method:   "ReserveNextFilesFromPath",
+			margs:    []interface{}{"2", 1, []string{".ext"}, sortMode},
+			fileList: []string{"file_1"},
+			output:   `"file_1" `,
+		},
+		{
+			name:     "OK_peek",
+			args:     append([]string{"peek"}, next...),
+			method:   "PeekNextFilesFromPath",
+			margs:    []interface{}{"2", 1, []string{".ext"}, sortMode},
+			fileList: []string{"file_1"},
+			output:   `"file_1" `,
+		},
+		{
+			name:     "OK_list",
+			args:     append([]string{"list"}, source...),
+			method:   "ListFilesFromPath",
+			margs:    []interface{}{"2", []string{".ext"}, sortMode},
+			fileList: []string{"file_1", "file_2"},
+			output:   `"file_1" "file_2" `,
+		},
+		{
+			name:     "OK_history",
+			args:     append([]string{"history"}, source...),
+			method:   "HistoryFromPath",
+			margs:    []interface{}{"2", []string{".ext"}, sortMode},
+			fileList: []string{"file_1"},
+			output:   `"file_1" `,
+		},
+		{
+			name:     "FAIL_history_from_proxy",
+			args:     append([]string{"history"}, source...),
+			method:   "HistoryFromPath",
+			margs:    []interface{}{"2", []string{".ext"}, sortMode},
+			err:      errProxy,
+			exitCode: _exitCodeError,
+		},
+		{
+			name:     "FAIL_peek_dry_run_not_supported",
+			args:     append([]string{"peek", "-dry_run"}, next...),
+			err:      errors.New("flag provided but not defined: -dry_run"),
+			exitCode: _exitCodeUsage,
+		},
+		{
+			name:     "FAIL_list_without_sort_mode",
+			args:     []string{"list", "-path", "2"},
+			err:      errSortModeIsEmpty,
+			exitCode: _exitCodeUsage,
+		},
+		{
+			name:     "FAIL_without_command",
+			args:     []string{},
+			err:      errCommandIsEmpty,
+			exitCode: _exitCodeUsage,
+		},
+		{
+			name:     "FAIL_unknown_command",
+			args:     []string{"unknown"},
+			err:      fmt.Errorf("%w: %s", errUnknownCommand, "unknown"),
+			exitCode: _exitCodeUsage,
+		},
+		{
+			name:     "OK_help",
+			args:     []string{"help"},
+			exitCode: 0,
+		},
+		{
+			name:     "OK_help_command",
+			args:     []string{"help", "status"},
+			err:      flag.ErrHelp,
+			exitCode: 0,
+		},
+		{
+			name:     "OK_command_help_flag",
+			args:     []string{"next", "-h"},
+			err:      flag.ErrHelp,
+			exitCode: 0,
+		},
+		{
+			name:     "FAIL_help_unknown_command",
+			args:     []string{"help", "unknown"},
+			err:      fmt.Errorf("%w: %s", errUnknownCommand, "unknown"),
+			exitCode: _exitCodeUsage,
+		},
+	}
+
+	originalUsageOutput := usageOutput
+
+	defer func() {
+		usageOutput = originalUsageOutput
+	}()
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			var usage bytes.Buffer
+
+			usageOutput = &usage
+
+			playlisterMock := playlisterMock{}
+			playlisterMock.Test(t)
+
+			if tc.method != "" {
+				playlisterMock.On(tc.method, tc.margs...).Return(tc.fileList, tc.err)
+			}
+
+			writerMock := writerMock{}
+			writerMock.Test(t)
+
+			if tc.output != "" {
+				for _, file := range tc.fileList {
+					writerMock.On("WriteString", `"`+file+`" `).Return(0, nil)
+				}
+
+				writerMock.On("Flush").Return(nil)
+			}
+
+			err := run(tc.args, newPlaylisterMock(&playlisterMock), &writerMock)
+			if tc.err == nil {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, tc.err.Error())
+			}
+
+			require.EqualValues(t, tc.exitCode, exitCode(err))
+			playlisterMock.AssertExpectations(t)
+			writerMock.AssertExpectations(t)
+
+			if tc.exitCode == _exitCodeUsage || tc.err == flag.ErrHelp || tc.name == "OK_help" {
+				require.Contains(t, usage.String(), "Usage: goplaylist")
+			}
+		})
+	}
+}
+
+func TestPrintUsage(t *testing.T) {
+	var usage bytes.Buffer
+
+	originalUsageOutput := usageOutput
+
+	defer func() {
+		usageOutput = originalUsageOutput
+	}()
+
+	usageOutput = &usage
+
+	printUsage()
+
+	for _, cmd := range commands() {
+		require.Contains(t, usage.String(), "  "+cmd.name+" ")
+	}
+
+	usage.Reset()
+
+	err := SeekFromPath([]string{"-h"}, nil)
+	require.EqualValues(t, flag.ErrHelp, err)
+	require.Contains(t, usage.String(), "Usage: goplaylist seek [flags] <file|index>")
+	require.Contains(t, usage.String(), "-sort_mode")
+}
+
+func TestExitCode(t *testing.T) {
+	require.EqualValues(t, 0, exitCode(nil))
+	require.EqualValues(t, 0, exitCode(flag.ErrHelp))
+	require.EqualValues(t, _exitCodeError, exitCode(errProxy))
+	require.EqualValues(t, _exitCodeUsage, exitCode(&usageError{err: errPathOriginIsEmpty}))
+	require.EqualValues(t, _exitCodePlaylistEnded, exitCode(fmt.Errorf("%w: 2", playlist.ErrPlaylistEnded)))
+
+	args := []string{"list", "-path", "2", "-extension", ".ext", "-sort_mode", "name", "-sort_key_regex", `(?P<n>\d+)`}
+	err := run(args, newPlaylisterMock(&playlisterMock{}), &writerMock{})
+	require.True(t, errors.Is(err, errSortKeyRegexIsUnused), err)
+	require.EqualValues(t, _exitCodeUsage, exitCode(err))
+}
@@ -6,8 +6,11 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/masch/goplaylist/internal/playlist"
 )
@@ -18,10 +21,45 @@ var (
 	errCountFilesIsEmpty        = errors.New("count files is empty")
 	errFilterExtensionsAreEmpty = errors.New("filter extensions are empty")
 	errUnknownFileSortMode      = errors.New("unknown file sort mode")
+	errUnknownSortDirection     = errors.New("unknown sort direction")
+	errSortKeyRegexIsEmpty      = errors.New("sort_key_regex is empty")
+	errSortKeyRegexIsUnused     = errors.New("sort_key_regex is given without the regex sort mode")
+	errUnknownSortKeyType       = errors.New("unknown sort key type")
+	errUnknownEndMode           = errors.New("unknown end mode")
+	errCountIsNotPositive       = errors.New("count is not positive")
+	errSeekTargetIsEmpty        = errors.New("seek target file or index is empty")
+	errCommandIsEmpty           = errors.New("command is empty")
+	errUnknownCommand           = errors.New("unknown command")
+)
+
+const (
+	_stateEnvironmentVariable = "GOPLAYLIST_STATE"
+	_stateFlagUsage           = "Specify the state file name used to resume the file list. " +
+		"Defaults to $GOPLAYLIST_STATE or $XDG_STATE_HOME/goplaylist/cfg.ini"
+
+	// _exitCodeError is the exit code when the command failed.
+	_exitCodeError = 1
+
+	// _exitCodeUsage is the exit code when the command line usage is wrong.
+	_exitCodeUsage = 2
+
+	// _exitCodePlaylistEnded is the exit code when there are no more files to list and the end mode is error.
+	_exitCodePlaylistEnded = 3
 )
 
 type playlister interface {
-	GetNextFilesFromPath(path string, count int, fileExtension []string, sortMode playlist.FileSortMode) ([]string, error)
+	GetNextFilesFromPath(path string, count int, fileExtension []string, sortMode playlist.SortOrder) ([]string, error)
+	PeekNextFilesFromPath(path string, count int, fileExtension []string, sortMode playlist.SortOrder) ([]string, error)
+	ReserveNextFilesFromPath(
+		path string, count int, fileExtension []string, sortMode playlist.SortOrder) ([]string, error)
+	AckFilesFromPath(path string, files []string) ([]string, error)
+	RewindFromPath(path string, count int, fileExtension []string, sortMode playlist.SortOrder) error
+	SeekFileFromPath(path string, file string, fileExtension []string, sortMode playlist.SortOrder) error
+	SeekIndexFromPath(path string, index int, fileExtension []string, sortMode playlist.SortOrder) error
+	ResetFromPath(path string) error
+	StatusFromPath(path string, fileExtension []string, sortMode playlist.SortOrder) (playlist.Status, error)
+	HistoryFromPath(path string, fileExtension []string, sortMode playlist.SortOrder) ([]string, error)
+	ListFilesFromPath(path string, fileExtension []string, sortMode playlist.SortOrder) ([]string, error)
 }
 
 type writer interface {
@@ -29,6 +67,21 @@ type writer interface {
 	Flush() error
 }
 
+// playlistConfig contains the settings used to build the playlist client.
+type playlistConfig struct {
+	// statePath is the state file name. If it is empty, the default state file name is used.
+	statePath string
+
+	// onEnd is the behavior when there are no more files to list.
+	onEnd playlist.EndMode
+
+	// pendingTTL is the duration after which the reservation of the files is cancelled.
+	pendingTTL time.Duration
+}
+
+// newPlaylisterFunc builds the playlist client from the configuration given.
+type newPlaylisterFunc func(cfg playlistConfig) (playlister, error)
+
 // arrayFlags defines custom flags to support array flags values.
 type arrayFlags []string
 
@@ -43,88 +96,114 @@ func (i *arrayFlags) Set(value string) error {
 
 var logFatal = log.Fatal //nolint // global used in order to test main result error
 
+var osExit = os.Exit //nolint // global used in order to test main exit code
+
+var usageOutput io.Writer = os.Stderr //nolint // global used in order to test the usage documentation
+
 func main() {
-	if err := run(os.Args[1:], &playlist.Playlist{}, bufio.NewWriter(os.Stdout)); err != nil {
+	err := run(os.Args[1:], newPlaylist, bufio.NewWriter(os.Stdout))
+
+	switch code := exitCode(err); code {
+	case 0:
+	case _exitCodeError:
 		logFatal(err)
+	default:
+		// The usage errors and the playlist end are reported with distinct exit codes
+		// in order to let scripts react to them
+		log.Print(err)
+		osExit(code)
 	}
 }
 
-func run(args []string, playlistClient playlister, playlistOutput writer) error {
-	fileList, err := GetNextFilesFromPath(args, playlistClient)
-	if err != nil {
-		return err
-	}
-
-	return writeOutput(fileList, playlistOutput)
+// usageError represents an error caused by a wrong command line usage.
+type usageError struct {
+	err error
 }
 
-// writeOutput prints on the writer given the file list given with a blank space between them.
-// It also surrounds each item with a double quote ("") in order to deal with a file name
-// that contain spaces under the command-line in GNU/Linux.
-func writeOutput(fileList []string, writer writer) error {
-	for _, file := range fileList {
-		if _, err := writer.WriteString(`"` + file + `" `); err != nil {
-			return err
-		}
-	}
+func (e *usageError) Error() string {
+	return e.err.Error()
+}
 
-	return writer.Flush()
+func (e *usageError) Unwrap() error {
+	return e.err
 }
 
-// GetNextFilesFromPath get next files list from the command line using command line flags.
-// If there was an error parsing the flags arguments, it prints the usage documentation on stdout.
-func GetNextFilesFromPath(args []string, playlistClient playlister) ([]string, error) {
-	// parse flags values from command line
-	var extensions arrayFlags
-
-	flags := flag.NewFlagSet("goplaylist", flag.ContinueOnError)
-	sortModeRaw := flags.String("sort_mode", "",
-		"Specify sort ascendant mode to list the files: name or timestamp_creation are supported")
-	path := flags.String("path", "", "Specify path to load file list")
-	countFiles := flags.Int("count", 0, "Specify file count to load from path")
-	flags.Var(&extensions, "extension",
-		"Specify file filter extension. Multiple extensions are supported by adding several -extension entry")
-
-	if err := flags.Parse(args); err != nil {
-		flags.Usage()
-		return nil, err
+// exitCode returns the process exit code of the error given:
+// 0 on success or help requested, 1 on failure, 2 on wrong usage and 3 when the playlist ended.
+func exitCode(err error) int {
+	var usageErr *usageError
+
+	switch {
+	case err == nil, errors.Is(err, flag.ErrHelp):
+		return 0
+	case errors.Is(err, playlist.ErrPlaylistEnded):
+		return _exitCodePlaylistEnded
+	case errors.As(err, &usageErr):
+		return _exitCodeUsage
+	default:
+		return _exitCodeError
 	}
+}
 
-	if *sortModeRaw == "" {
-		flags.Usage()
-		return nil, errSortModeIsEmpty
-	}
+// newPlaylist returns the playlist client storing its state on the state path configured.
+// When there is no state path configured, the state is stored on the XDG state directory
+// and the cfg.ini file of the current working directory used by previous versions is migrated there
+// by the first command which writes the state.
+func newPlaylist(cfg playlistConfig) (playlister, error) {
+	logger := log.New(os.Stderr, "", log.LstdFlags)
 
-	if *path == "" {
-		flags.Usage()
-		return nil, errPathOriginIsEmpty
-	}
+	store := playlist.NewIniStateStore(cfg.statePath)
 
-	if *countFiles == 0 {
-		flags.Usage()
-		return nil, errCountFilesIsEmpty
-	}
+	if cfg.statePath == "" {
+		statePath, err := playlist.DefaultStateFileName()
+		if err != nil {
+			return nil, err
+		}
 
-	if extensions == nil {
-		flags.Usage()
-		return nil, errFilterExtensionsAreEmpty
+		store = playlist.NewMigratingIniStateStore(statePath, playlist.LegacyStateFileName, logger)
 	}
 
-	var sortMode playlist.FileSortMode
+	return &playlist.Playlist{
+		Store:      store,
+		OnEnd:      cfg.onEnd,
+		PendingTTL: cfg.pendingTTL,
+		Logger:     logger,
+	}, nil
+}
 
-	switch *sortModeRaw {
-	case "name":
-		sortMode = playlist.FileSortModeFileNameAsc
-	case "timestamp_creation":
-		sortMode = playlist.FileSortModeTimestampCreationAsc
+// run executes the command given as the first argument with the rest of the arguments.
+// If the first argument is a flag, the next files are got and saved as listed directly
+// as the next command with the auto_ack flag does.
+// The errors which happen before building the playlist client are considered usage errors.
+func run(args []string, newPlaylister newPlaylisterFunc, playlistOutput writer) error {
+	var cmdRun commandRunFunc
+
+	switch {
+	case len(args) == 0:
+		printUsage()
+		return &usageError{err: errCommandIsEmpty}
+	case strings.HasPrefix(args[0], "-"):
+		cmdRun = runGetNextFiles
 	default:
-		return nil, fmt.Errorf("%w: %s", errUnknownFileSortMode, *sortModeRaw)
+		cmd, found := findCommand(args[0])
+		if !found {
+			printUsage()
+			return &usageError{err: fmt.Errorf("%w: %s", errUnknownCommand, args[0])}
+		}
+
+		cmdRun, args = cmd.run, args[1:]
 	}
 
-	fileList, err := playlistClient.GetNextFilesFromPath(*path, *countFiles, extensions, sortMode)
-	if err != nil {
-		return nil, err
+	built := false
+
+	err := cmdRun(args, func(cfg playlistConfig) (playlister, error) {
+		built = true
+		return newPlaylister(cfg)
+	}, playlistOutput)
+
+	if err != nil && !built && !errors.Is(err, flag.ErrHelp) {
+		return &usageError{err: err}
 	}
 
-	return fileList, nil
+	return err
 }
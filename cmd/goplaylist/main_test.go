@@ -1,9 +1,15 @@
 package main
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
@@ -70,23 +76,200 @@ type (
 )
 
 func TestMainFunc(t *testing.T) {
-	var fatalErrors []string
+	var (
+		fatalErrors []string
+		exitCodes   []int
+		logOutput   bytes.Buffer
+	)
 
-	originalLogFatal := logFatal
+	originalLogFatal, originalOsExit, originalUsageOutput := logFatal, osExit, usageOutput
 
 	defer func() {
-		logFatal = originalLogFatal
+		logFatal, osExit, usageOutput = originalLogFatal, originalOsExit, originalUsageOutput
+		log.SetOutput(os.Stderr)
 	}()
 
 	logFatal = func(v ...interface{}) {
 		fatalErrors = append(fatalErrors, fmt.Sprint(v...))
 	}
+	osExit = func(code int) {
+		exitCodes = append(exitCodes, code)
+	}
+	usageOutput = ioutil.Discard
+
+	log.SetOutput(&logOutput)
 
 	main()
 
 	for _, gotErr := range fatalErrors {
 		require.Contains(t, gotErr, "flag provided but not defined: ")
 	}
+
+	// The test flags are not defined, so it is a usage error
+	require.Contains(t, logOutput.String(), "flag provided but not defined: ")
+	require.EqualValues(t, []int{_exitCodeUsage}, exitCodes)
+}
+
+func TestMainFuncPlaylistEnded(t *testing.T) {
+	directory, err := ioutil.TempDir("", "goplaylist")
+	require.NoError(t, err)
+
+	defer func() {
+		require.NoError(t, os.RemoveAll(directory))
+	}()
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(directory, "file_1.ext"), nil, 0o600))
+
+	var exitCodes []int
+
+	originalArgs, originalLogFatal, originalOsExit := os.Args, logFatal, osExit
+
+	defer func() {
+		os.Args, logFatal, osExit = originalArgs, originalLogFatal, originalOsExit
+	}()
+
+	osExit = func(code int) {
+		exitCodes = append(exitCodes, code)
+	}
+
+	logFatal = func(v ...interface{}) {
+		require.Fail(t, "unexpected fatal error", v...)
+	}
+	os.Args = []string{
+		"goplaylist", "-sort_mode", "name", "-path", directory, "-count", "1", "-extension", ".ext",
+		"-on_end", "error", "-state", filepath.Join(directory, "state.ini"),
+	}
+
+	// The first execution lists the only file and the second one reaches the end
+	main()
+	main()
+
+	require.EqualValues(t, []int{_exitCodePlaylistEnded}, exitCodes)
+}
+
+func TestParseEndMode(t *testing.T) {
+	tt := []struct {
+		onEndRaw string
+		onEnd    playlist.EndMode
+		err      error
+	}{
+		{onEndRaw: "stop", onEnd: playlist.EndModeStop},
+		{onEndRaw: "loop", onEnd: playlist.EndModeLoop},
+		{onEndRaw: "error", onEnd: playlist.EndModeError},
+		{onEndRaw: "unknown", err: fmt.Errorf("%w: %s", errUnknownEndMode, "unknown")},
+	}
+
+	for _, tc := range tt {
+		got, err := parseEndMode(tc.onEndRaw)
+		require.EqualValues(t, tc.err, err)
+		require.EqualValues(t, tc.onEnd, got)
+	}
+}
+
+func TestParseSortMode(t *testing.T) {
+	tt := []struct {
+		sortModeRaw string
+		sortMode    playlist.SortOrder
+		err         error
+	}{
+		{sortModeRaw: "name", sortMode: playlist.FileSortModeFileNameAsc},
+		{sortModeRaw: "timestamp_creation", sortMode: playlist.FileSortModeTimestampCreationAsc},
+		{sortModeRaw: "natural", sortMode: playlist.FileSortModeNaturalAsc},
+		{sortModeRaw: "mtime", sortMode: playlist.FileSortModeModTimeAsc},
+		{sortModeRaw: "birthtime", sortMode: playlist.FileSortModeBirthTimeAsc},
+		{sortModeRaw: "dir", sortMode: playlist.FileSortModeDirectoryAsc},
+		{sortModeRaw: "shuffle", sortMode: playlist.FileSortModeShuffle},
+		{sortModeRaw: "episode", sortMode: playlist.FileSortModeEpisodeAsc},
+		{sortModeRaw: "tags", sortMode: playlist.FileSortModeTagsAsc},
+		{sortModeRaw: "interleave", sortMode: playlist.FileSortModeInterleave},
+		{sortModeRaw: "size", sortMode: playlist.FileSortModeSizeAsc},
+		{sortModeRaw: "duration", sortMode: playlist.FileSortModeDurationAsc},
+		{
+			sortModeRaw: "duration:desc",
+			sortMode:    playlist.SortSpec{{Mode: playlist.FileSortModeDurationAsc, Descending: true}},
+		},
+		{
+			sortModeRaw: "interleave,episode",
+			sortMode:    playlist.SortSpec{{Mode: playlist.FileSortModeInterleave}, {Mode: playlist.FileSortModeEpisodeAsc}},
+		},
+		{sortModeRaw: "name:asc", sortMode: playlist.FileSortModeFileNameAsc},
+		{
+			sortModeRaw: "mtime:desc,name:asc",
+			sortMode: playlist.SortSpec{
+				{Mode: playlist.FileSortModeModTimeAsc, Descending: true},
+				{Mode: playlist.FileSortModeFileNameAsc},
+			},
+		},
+		{
+			sortModeRaw: "dir:asc,natural",
+			sortMode:    playlist.SortSpec{{Mode: playlist.FileSortModeDirectoryAsc}, {Mode: playlist.FileSortModeNaturalAsc}},
+		},
+		{sortModeRaw: "unknown", err: fmt.Errorf("%w: %s", errUnknownFileSortMode, "unknown")},
+		{sortModeRaw: "name,unknown:desc", err: fmt.Errorf("%w: %s", errUnknownFileSortMode, "unknown")},
+		{sortModeRaw: "name:up", err: fmt.Errorf("%w: %s", errUnknownSortDirection, "name:up")},
+	}
+
+	for _, tc := range tt {
+		got, err := parseSortMode(tc.sortModeRaw, nil)
+		require.EqualValues(t, tc.err, err)
+		require.EqualValues(t, tc.sortMode, got)
+	}
+}
+
+func TestSourceFlagsSortOrder(t *testing.T) {
+	keyRegexp, err := playlist.NewSortKeyRegexp(`(?P<date>\d{4}-\d{2}-\d{2}).*part(?P<n>\d+)`,
+		playlist.SortKeyTypeDate, playlist.SortKeyTypeNumber)
+	require.NoError(t, err)
+
+	tt := []struct {
+		name     string
+		flags    sourceFlags
+		sortMode playlist.SortOrder
+		err      error
+	}{
+		{
+			name:     "OK_regex_by_default",
+			flags:    sourceFlags{sortKeyRegex: keyRegexp.String(), sortKeyTypesRaw: "date,number"},
+			sortMode: playlist.SortSpec{{Mode: playlist.FileSortModeRegexp, Regexp: keyRegexp}},
+		},
+		{
+			name:  "OK_regex_descendant_then_name",
+			flags: sourceFlags{sortModeRaw: "regex:desc,name", sortKeyRegex: keyRegexp.String(), sortKeyTypesRaw: "date,number"},
+			sortMode: playlist.SortSpec{
+				{Mode: playlist.FileSortModeRegexp, Descending: true, Regexp: keyRegexp},
+				{Mode: playlist.FileSortModeFileNameAsc},
+			},
+		},
+		{
+			name:  "FAIL_regex_unused",
+			flags: sourceFlags{sortModeRaw: "name", sortKeyRegex: keyRegexp.String()},
+			err:   errSortKeyRegexIsUnused,
+		},
+		{
+			name:  "FAIL_regex_is_empty",
+			flags: sourceFlags{sortModeRaw: "name,regex"},
+			err:   errSortKeyRegexIsEmpty,
+		},
+		{
+			name:  "FAIL_unknown_type",
+			flags: sourceFlags{sortKeyRegex: keyRegexp.String(), sortKeyTypesRaw: "date,time"},
+			err:   errUnknownSortKeyType,
+		},
+		{
+			name:  "FAIL_too_many_types",
+			flags: sourceFlags{sortKeyRegex: `(?P<n>\d+)`, sortKeyTypesRaw: "number,number"},
+			err:   playlist.ErrInvalidSortKeyRegexp,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := tc.flags.sortOrder()
+			require.True(t, errors.Is(err, tc.err), err)
+			require.EqualValues(t, tc.sortMode, got)
+		})
+	}
 }
 
 func TestRun(t *testing.T) { //nolint // function tool large because of BDD mechanism
@@ -268,7 +451,7 @@ func TestRun(t *testing.T) { //nolint // function tool large because of BDD mech
 					Return(writerProxy.flush.res.err)
 			}
 
-			err := run(tc.suite.input.args, &playlisterMock, &writerMock)
+			err := run(tc.suite.input.args, newPlaylisterMock(&playlisterMock), &writerMock)
 
 			require.EqualValues(t, tc.suite.expect.err, err)
 		})
@@ -371,6 +554,19 @@ func TestGetNextFilesFromPath(t *testing.T) { //nolint // function tool large be
 			},
 			proxy: getNextFilesFromPathProxy{},
 		},
+		{
+			suite: suite{
+				name: "FAIL_With_unknown_end_mode_argument",
+				input: input{
+					args: []string{"-sort_mode", "name", "-path", "2", "-count", "1", "-extension", ".ext", "-on_end", "1"},
+				},
+				expect: expect{
+					fileList: nil,
+					err:      fmt.Errorf("%w: %s", errUnknownEndMode, "1"),
+				},
+			},
+			proxy: getNextFilesFromPathProxy{},
+		},
 		{
 			suite: suite{
 				name: "FAIL_With_error_from_playlist_proxy",
@@ -455,7 +651,7 @@ func TestGetNextFilesFromPath(t *testing.T) { //nolint // function tool large be
 				tc.proxy.req.path, tc.proxy.req.count, tc.proxy.req.fileExtension, tc.proxy.req.sortMode).
 				Return(tc.proxy.res.fileList, tc.proxy.res.err)
 
-			got, err := GetNextFilesFromPath(tc.suite.input.args, &playlisterMock)
+			got, err := GetNextFilesFromPath(tc.suite.input.args, newPlaylisterMock(&playlisterMock))
 
 			require.EqualValues(t, tc.suite.expect.err, err)
 			require.EqualValues(t, tc.suite.expect.fileList, got)
@@ -463,6 +659,274 @@ func TestGetNextFilesFromPath(t *testing.T) { //nolint // function tool large be
 	}
 }
 
+func TestGetNextFilesFromPathDryRun(t *testing.T) {
+	playlisterMock := playlisterMock{}
+	playlisterMock.Test(t)
+	playlisterMock.On("PeekNextFilesFromPath",
+		"2", 1, []string{".ext"}, playlist.FileSortMode(playlist.FileSortModeFileNameAsc)).
+		Return([]string{"file_1"}, nil)
+
+	got, err := GetNextFilesFromPath(
+		[]string{"-sort_mode", "name", "-path", "2", "-count", "1", "-extension", ".ext", "-dry_run"},
+		newPlaylisterMock(&playlisterMock))
+	require.NoError(t, err)
+	require.EqualValues(t, []string{"file_1"}, got)
+	playlisterMock.AssertNotCalled(t, "GetNextFilesFromPath", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestRunReserveAndAck(t *testing.T) {
+	sortMode := playlist.FileSortMode(playlist.FileSortModeFileNameAsc)
+
+	playlisterMock := playlisterMock{}
+	playlisterMock.Test(t)
+	playlisterMock.On("ReserveNextFilesFromPath", "2", 1, []string{".ext"}, sortMode).
+		Return([]string{"file_1"}, nil)
+	playlisterMock.On("GetNextFilesFromPath", "2", 1, []string{".ext"}, sortMode).
+		Return([]string{"file_2"}, nil)
+	playlisterMock.On("AckFilesFromPath", "2", []string{"file_1"}).
+		Return([]string{"file_1"}, nil)
+	playlisterMock.On("AckFilesFromPath", "2", []string{}).
+		Return([]string(nil), errProxy)
+
+	var configs []playlistConfig
+
+	newPlaylister := func(cfg playlistConfig) (playlister, error) {
+		configs = append(configs, cfg)
+		return &playlisterMock, nil
+	}
+
+	writerMock := writerMock{}
+	writerMock.Test(t)
+	writerMock.On("WriteString", `"file_1" `).Return(0, nil)
+	writerMock.On("WriteString", `"file_2" `).Return(0, nil)
+	writerMock.On("Flush").Return(nil)
+
+	args := []string{"-sort_mode", "name", "-path", "2", "-count", "1", "-extension", ".ext"}
+
+	require.NoError(t, run(append([]string{"next", "-pending_ttl", "1h"}, args...), newPlaylister, &writerMock))
+	require.NoError(t, run(append([]string{"next", "-auto_ack"}, args...), newPlaylister, &writerMock))
+	require.NoError(t, run([]string{"ack", "-path", "2", "file_1"}, newPlaylister, &writerMock))
+	require.EqualValues(t, errProxy, run([]string{"ack", "-path", "2"}, newPlaylister, &writerMock))
+	require.True(t, errors.Is(run([]string{"ack", "file_1"}, newPlaylister, &writerMock), errPathOriginIsEmpty))
+
+	require.EqualValues(t, []playlistConfig{{pendingTTL: time.Hour}, {}, {}, {}}, configs)
+	playlisterMock.AssertNumberOfCalls(t, "ReserveNextFilesFromPath", 1)
+	playlisterMock.AssertNumberOfCalls(t, "GetNextFilesFromPath", 1)
+
+	// The reserve flags are only supported by the next subcommand
+	require.Error(t, run(append([]string{"-auto_ack"}, args...), newPlaylister, &writerMock))
+}
+
+func TestRunMoveLastFile(t *testing.T) {
+	sortMode := playlist.FileSortMode(playlist.FileSortModeFileNameAsc)
+	source := []string{"-sort_mode", "name", "-path", "2", "-extension", ".ext"}
+
+	tt := []struct {
+		name   string
+		args   []string
+		method string
+		margs  []interface{}
+		err    error
+	}{
+		{
+			name:   "OK_back_default_count",
+			args:   append([]string{"back"}, source...),
+			method: "RewindFromPath",
+			margs:  []interface{}{"2", 1, []string{".ext"}, sortMode},
+		},
+		{
+			name:   "OK_back",
+			args:   append([]string{"back", "-n", "3"}, source...),
+			method: "RewindFromPath",
+			margs:  []interface{}{"2", 3, []string{".ext"}, sortMode},
+		},
+		{
+			name: "FAIL_back_not_positive_count",
+			args: append([]string{"back", "-n", "0"}, source...),
+			err:  errCountIsNotPositive,
+		},
+		{
+			name: "FAIL_back_without_extension",
+			args: []string{"back", "-sort_mode", "name", "-path", "2"},
+			err:  errFilterExtensionsAreEmpty,
+		},
+		{
+			name:   "OK_seek_file",
+			args:   append(append([]string{"seek"}, source...), "2/file_2.ext"),
+			method: "SeekFileFromPath",
+			margs:  []interface{}{"2", "2/file_2.ext", []string{".ext"}, sortMode},
+		},
+		{
+			name:   "OK_seek_index",
+			args:   append(append([]string{"seek"}, source...), "4"),
+			method: "SeekIndexFromPath",
+			margs:  []interface{}{"2", 4, []string{".ext"}, sortMode},
+		},
+		{
+			name:   "FAIL_seek_from_proxy",
+			args:   append(append([]string{"seek"}, source...), "4"),
+			method: "SeekIndexFromPath",
+			margs:  []interface{}{"2", 4, []string{".ext"}, sortMode},
+			err:    errProxy,
+		},
+		{
+			name: "FAIL_seek_without_target",
+			args: append([]string{"seek"}, source...),
+			err:  errSeekTargetIsEmpty,
+		},
+		{
+			name:   "OK_reset",
+			args:   []string{"reset", "-path", "2"},
+			method: "ResetFromPath",
+			margs:  []interface{}{"2"},
+		},
+		{
+			name: "FAIL_reset_without_path",
+			args: []string{"reset"},
+			err:  errPathOriginIsEmpty,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			playlisterMock := playlisterMock{}
+			playlisterMock.Test(t)
+
+			if tc.method != "" {
+				playlisterMock.On(tc.method, tc.margs...).Return(tc.err)
+			}
+
+			writerMock := writerMock{}
+			writerMock.Test(t)
+			writerMock.On("Flush").Return(nil)
+
+			err := run(tc.args, newPlaylisterMock(&playlisterMock), &writerMock)
+			require.True(t, errors.Is(err, tc.err))
+			playlisterMock.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRunStatus(t *testing.T) {
+	sortMode := playlist.FileSortMode(playlist.FileSortModeFileNameAsc)
+	source := []string{"-sort_mode", "name", "-path", "2", "-extension", ".ext"}
+	status := playlist.Status{Last: "2/file_1.ext", Index: 1, Total: 3, Remaining: 2, Progress: 100.0 / 3}
+
+	tt := []struct {
+		name   string
+		args   []string
+		output string
+		err    error
+	}{
+		{
+			name:   "OK_text",
+			args:   append([]string{"status"}, source...),
+			output: "last: 2/file_1.ext\nindex: 1/3\nremaining: 2\npending: 0\nprogress: 33.3%\n",
+		},
+		{
+			name: "OK_json",
+			args: append([]string{"status", "-json"}, source...),
+			output: `{"last":"2/file_1.ext","index":1,"total":3,"remaining":2,"pending":0,` +
+				`"progress":33.333333333333336}` + "\n",
+		},
+		{
+			name: "FAIL_from_proxy",
+			args: append([]string{"status"}, source...),
+			err:  errProxy,
+		},
+		{
+			name: "FAIL_without_path",
+			args: []string{"status", "-sort_mode", "name"},
+			err:  errPathOriginIsEmpty,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			playlisterMock := playlisterMock{}
+			playlisterMock.Test(t)
+			playlisterMock.On("StatusFromPath", "2", []string{".ext"}, sortMode).Return(status, tc.err)
+
+			writerMock := writerMock{}
+			writerMock.Test(t)
+
+			if tc.output != "" {
+				writerMock.On("WriteString", tc.output).Return(len(tc.output), nil)
+				writerMock.On("Flush").Return(nil)
+			}
+
+			err := run(tc.args, newPlaylisterMock(&playlisterMock), &writerMock)
+			require.True(t, errors.Is(err, tc.err))
+			writerMock.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetNextFilesFromPathStatePath(t *testing.T) {
+	args := []string{"-sort_mode", "name", "-path", "2", "-count", "1", "-extension", ".ext"}
+
+	originalState, stateFound := os.LookupEnv(_stateEnvironmentVariable)
+
+	defer func() {
+		if stateFound {
+			require.NoError(t, os.Setenv(_stateEnvironmentVariable, originalState))
+		} else {
+			require.NoError(t, os.Unsetenv(_stateEnvironmentVariable))
+		}
+	}()
+
+	tt := []struct {
+		name      string
+		args      []string
+		env       string
+		statePath string
+	}{
+		{name: "OK_default", args: args, env: "", statePath: ""},
+		{name: "OK_from_environment", args: args, env: "/env/state.ini", statePath: "/env/state.ini"},
+		{
+			name:      "OK_from_flag",
+			args:      append([]string{"-state", "/flag/state.ini"}, args...),
+			env:       "/env/state.ini",
+			statePath: "/flag/state.ini",
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			require.NoError(t, os.Setenv(_stateEnvironmentVariable, tc.env))
+
+			playlisterMock := playlisterMock{}
+			playlisterMock.Test(t)
+			playlisterMock.On("GetNextFilesFromPath",
+				"2", 1, []string{".ext"}, playlist.FileSortMode(playlist.FileSortModeFileNameAsc)).
+				Return([]string{"file_1"}, nil)
+
+			var got playlistConfig
+
+			_, err := GetNextFilesFromPath(tc.args, func(cfg playlistConfig) (playlister, error) {
+				got = cfg
+				return &playlisterMock, nil
+			})
+			require.NoError(t, err)
+			require.EqualValues(t, playlistConfig{statePath: tc.statePath}, got)
+		})
+	}
+
+	_, err := GetNextFilesFromPath(args, func(cfg playlistConfig) (playlister, error) {
+		return nil, errProxy
+	})
+	require.EqualValues(t, errProxy, err)
+}
+
+func newPlaylisterMock(m *playlisterMock) newPlaylisterFunc {
+	return func(playlistConfig) (playlister, error) {
+		return m, nil
+	}
+}
+
 type writerMock struct {
 	mock.Mock
 }
@@ -482,7 +946,65 @@ type playlisterMock struct {
 }
 
 func (m *playlisterMock) GetNextFilesFromPath(
-	path string, count int, fileExtension []string, sortMode playlist.FileSortMode) ([]string, error) {
+	path string, count int, fileExtension []string, sortMode playlist.SortOrder) ([]string, error) {
 	args := m.Called(path, count, fileExtension, sortMode)
 	return args.Get(0).([]string), args.Error(1)
 }
+
+func (m *playlisterMock) PeekNextFilesFromPath(
+	path string, count int, fileExtension []string, sortMode playlist.SortOrder) ([]string, error) {
+	args := m.Called(path, count, fileExtension, sortMode)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *playlisterMock) ReserveNextFilesFromPath(
+	path string, count int, fileExtension []string, sortMode playlist.SortOrder) ([]string, error) {
+	args := m.Called(path, count, fileExtension, sortMode)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *playlisterMock) AckFilesFromPath(path string, files []string) ([]string, error) {
+	args := m.Called(path, files)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *playlisterMock) RewindFromPath(
+	path string, count int, fileExtension []string, sortMode playlist.SortOrder) error {
+	args := m.Called(path, count, fileExtension, sortMode)
+	return args.Error(0)
+}
+
+func (m *playlisterMock) SeekFileFromPath(
+	path string, file string, fileExtension []string, sortMode playlist.SortOrder) error {
+	args := m.Called(path, file, fileExtension, sortMode)
+	return args.Error(0)
+}
+
+func (m *playlisterMock) SeekIndexFromPath(
+	path string, index int, fileExtension []string, sortMode playlist.SortOrder) error {
+	args := m.Called(path, index, fileExtension, sortMode)
+	return args.Error(0)
+}
+
+func (m *playlisterMock) ResetFromPath(path string) error {
+	args := m.Called(path)
+	return args.Error(0)
+}
+
+func (m *playlisterMock) StatusFromPath(
+	path string, fileExtension []string, sortMode playlist.SortOrder) (playlist.Status, error) {
+	args := m.Called(path, fileExtension, sortMode)
+	return args.Get(0).(playlist.Status), args.Error(1)
+}
+
+func (m *playlisterMock) HistoryFromPath(
+	path string, fileExtension []string, sortMode playlist.SortOrder) ([]string, error) {
+	args := m.Called(path, fileExtension, sortMode)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *playlisterMock) ListFilesFromPath(
+	path string, fileExtension []string, sortMode playlist.SortOrder) ([]string, error) {
+	args := m.Called(path, fileExtension, sortMode)
+	return args.Get(0).([]string), args.Error(1)
+}
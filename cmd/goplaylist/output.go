@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+var errUnknownFormat = errors.New("unknown output format")
+
+// formatFlag is the output format flag value. It only accepts the formats of the playlist writers supported.
+type formatFlag string
+
+func (f *formatFlag) String() string {
+	return string(*f)
+}
+
+func (f *formatFlag) Set(value string) error {
+	if _, found := playlistWriters()[value]; !found {
+		return fmt.Errorf("%w: %s", errUnknownFormat, value)
+	}
+
+	*f = formatFlag(value)
+
+	return nil
+}
+
+// nulFlag is the boolean flag value which selects the nul output format when it is set.
+type nulFlag struct {
+	format *formatFlag
+}
+
+func (f nulFlag) IsBoolFlag() bool {
+	return true
+}
+
+func (f nulFlag) String() string {
+	return "false"
+}
+
+func (f nulFlag) Set(value string) error {
+	set, err := strconv.ParseBool(value)
+	if err != nil {
+		return err
+	}
+
+	if set {
+		*f.format = _formatNUL
+	}
+
+	return nil
+}
+
+// outputFlags contains the flags values which define how a file list is printed.
+type outputFlags struct {
+	format    formatFlag
+	template  templateWriter
+	output    string
+	pathStyle pathStyleFlag
+	pathBase  string
+}
+
+// register defines the output flags on the flag set given.
+func (f *outputFlags) register(flags *flag.FlagSet) {
+	f.format = _formatQuoted
+	flags.Var(&f.format, "format", "Specify the output format: "+strings.Join(playlistFormats(), ", ")+
+		" are supported. The m3u format is encoded on ISO-8859-1 and the rest on UTF-8")
+	flags.Var(nulFlag{format: &f.format}, "0", "Print the files terminated by a NUL character as xargs -0 expects. "+
+		"It is an alias of -format=nul")
+	flags.Var(templateFlag{writer: &f.template}, "template", "Specify the Go text/template rendered for each file. "+
+		"It overrides the output format. The fields Path, Base, Dir, Ext, Size, ModTime, Index and Total are available. "+
+		"The header and footer blocks are rendered before and after the files with the fields Count and Total")
+	flags.Var(templateFlag{writer: &f.template, file: true}, "template_file",
+		"Specify the file name of the Go text/template rendered for each file as the template flag does")
+	flags.StringVar(&f.output, "output", "", "Specify the file name to write the output instead of the standard output")
+
+	f.pathStyle = _pathStyleListed
+	flags.Var(&f.pathStyle, "path_style", "Specify how the file paths are printed: listed, relative, absolute or uri "+
+		"(percent-encoded file URI) are supported")
+	flags.StringVar(&f.pathBase, "path_base", "", "Specify the directory the relative file paths are printed from. "+
+		"Defaults to the directory of the output file or the current working directory")
+}
+
+// write prints the file list given following the output format on the writer given
+// or on the output file name when it is set.
+func (f *outputFlags) write(listing fileListing, playlistOutput writer) error {
+	playlistWriter := f.playlistWriter()
+
+	entries, err := newPlaylistEntries(listing, f.pathRenderer(), playlistWriter)
+	if err != nil {
+		return err
+	}
+
+	if f.output == "" {
+		return playlistWriter.writePlaylist(entries, playlistOutput)
+	}
+
+	file, err := os.Create(f.output)
+	if err != nil {
+		return err
+	}
+
+	if err := playlistWriter.writePlaylist(entries, bufio.NewWriter(file)); err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+// playlistWriter returns the template playlist writer if a template was given or the playlist writer of the format.
+func (f *outputFlags) playlistWriter() playlistWriter {
+	if f.template.tmpl != nil {
+		return f.template
+	}
+
+	return playlistWriters()[string(f.format)]
+}
+
+// pathRenderer returns the renderer of the file paths following the path flags.
+func (f *outputFlags) pathRenderer() pathRenderer {
+	base := f.pathBase
+
+	if base == "" && f.output != "" {
+		base = filepath.Dir(f.output)
+	}
+
+	return pathRenderer{style: f.pathStyle, base: base}
+}
+
+// newPlaylistEntries returns the playlist entries of the file listing given rendering the paths
+// with the path renderer given. The position of the files on the sorted file list is only found
+// when the playlist writer given prints it.
+func newPlaylistEntries(
+	listing fileListing, pathRenderer pathRenderer, playlistWriter playlistWriter) ([]playlistEntry, error) {
+	entries := make([]playlistEntry, 0, len(listing.files))
+
+	for _, file := range listing.files {
+		entries = append(entries, pathRenderer.entry(file))
+	}
+
+	indexedWriter, ok := playlistWriter.(indexedPlaylistWriter)
+	if !ok || !indexedWriter.indexed() || listing.sorted == nil || len(entries) == 0 {
+		return entries, nil
+	}
+
+	sortedFiles, err := listing.sorted()
+	if err != nil {
+		return nil, err
+	}
+
+	indexes := make(map[string]int, len(sortedFiles))
+
+	for i, file := range sortedFiles {
+		indexes[file] = i + 1
+	}
+
+	for i := range entries {
+		entries[i].index, entries[i].total = indexes[entries[i].file], len(sortedFiles)
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,317 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/masch/goplaylist/internal/playlist"
+)
+
+func TestOutputFormats(t *testing.T) {
+	fileList := []string{"/music/01 - Intro.mp3", "/music/Café ☕.flac"}
+
+	tt := []struct {
+		name   string
+		format string
+		output string
+		err    error
+	}{
+		{
+			name:   "OK_quoted",
+			format: "quoted",
+			output: `"/music/01 - Intro.mp3" "/music/Café ☕.flac" `,
+		},
+		{
+			name:   "OK_m3u8",
+			format: "m3u8",
+			output: "#EXTM3U\n" +
+				"#EXTINF:-1,01 - Intro\n/music/01 - Intro.mp3\n" +
+				"#EXTINF:-1,Café ☕\n/music/Café ☕.flac\n",
+		},
+		{
+			name:   "OK_m3u",
+			format: "m3u",
+			output: "#EXTM3U\n" +
+				"#EXTINF:-1,01 - Intro\n/music/01 - Intro.mp3\n" +
+				"#EXTINF:-1,Caf\xe9 ?\nfile:///music/Caf%C3%A9%20%E2%98%95.flac\n",
+		},
+		{
+			name:   "OK_shell",
+			format: "shell",
+			output: `'/music/01 - Intro.mp3' '/music/Café ☕.flac' `,
+		},
+		{
+			name:   "OK_nul",
+			format: "nul",
+			output: "/music/01 - Intro.mp3\x00/music/Café ☕.flac\x00",
+		},
+		{
+			name:   "FAIL_unknown_format",
+			format: "unknown",
+			err:    fmt.Errorf("invalid value \"unknown\" for flag -format: %w: unknown", errUnknownFormat),
+		},
+	}
+
+	sortMode := playlist.FileSortMode(playlist.FileSortModeFileNameAsc)
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			var output bytes.Buffer
+
+			originalUsageOutput := usageOutput
+			usageOutput = ioutil.Discard
+
+			defer func() {
+				usageOutput = originalUsageOutput
+			}()
+
+			playlisterMock := playlisterMock{}
+			playlisterMock.Test(t)
+
+			if tc.err == nil {
+				playlisterMock.On("ListFilesFromPath", "2", []string{".ext"}, sortMode).Return(fileList, nil)
+			}
+
+			args := []string{"list", "-format", tc.format, "-sort_mode", "name", "-path", "2", "-extension", ".ext"}
+			if tc.format == "nul" {
+				// The -0 flag is an alias of the nul format
+				args = append([]string{"list", "-0"}, args[3:]...)
+			}
+
+			err := run(args, newPlaylisterMock(&playlisterMock), bufio.NewWriter(&output))
+			if tc.err != nil {
+				require.EqualError(t, err, tc.err.Error())
+				require.EqualValues(t, _exitCodeUsage, exitCode(err))
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.output, output.String())
+			playlisterMock.AssertExpectations(t)
+		})
+	}
+}
+
+func TestOutputFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goplaylist")
+	require.NoError(t, err)
+
+	defer os.RemoveAll(dir)
+
+	fileName := filepath.Join(dir, "playlist.m3u8")
+	flags := outputFlags{format: _formatM3U8, output: fileName}
+
+	var stdout bytes.Buffer
+
+	err = flags.write(fileListing{files: []string{"/music/song.mp3"}}, bufio.NewWriter(&stdout))
+	require.NoError(t, err)
+	require.Empty(t, stdout.String())
+
+	content, err := ioutil.ReadFile(fileName)
+	require.NoError(t, err)
+	require.Equal(t, "#EXTM3U\n#EXTINF:-1,song\n/music/song.mp3\n", string(content))
+
+	flags.output = filepath.Join(dir, "missing", "playlist.m3u8")
+	require.Error(t, flags.write(fileListing{files: []string{"/music/song.mp3"}}, bufio.NewWriter(&stdout)))
+}
+
+func TestOutputJSON(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goplaylist")
+	require.NoError(t, err)
+
+	defer os.RemoveAll(dir)
+
+	modTime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	fileList := []string{filepath.Join(dir, "file_1.ext"), filepath.Join(dir, `file "2".ext`)}
+
+	for _, file := range fileList {
+		require.NoError(t, ioutil.WriteFile(file, []byte("content"), 0o600))
+		require.NoError(t, os.Chtimes(file, modTime, modTime))
+	}
+
+	sortMode := playlist.FileSortMode(playlist.FileSortModeFileNameAsc)
+
+	for _, format := range []string{"json", "ndjson"} {
+		var output bytes.Buffer
+
+		playlisterMock := playlisterMock{}
+		playlisterMock.Test(t)
+		playlisterMock.On("PeekNextFilesFromPath", dir, 1, []string{".ext"}, sortMode).Return(fileList[1:], nil)
+		playlisterMock.On("ListFilesFromPath", dir, []string{".ext"}, sortMode).Return(fileList, nil)
+
+		args := []string{"peek", "-format", format, "-count", "1", "-sort_mode", "name", "-path", dir, "-extension", ".ext"}
+
+		err := run(args, newPlaylisterMock(&playlisterMock), bufio.NewWriter(&output))
+		require.NoError(t, err)
+		playlisterMock.AssertExpectations(t)
+
+		var entry jsonEntry
+
+		content := strings.TrimSpace(output.String())
+		if format == "json" {
+			var entries []jsonEntry
+
+			require.NoError(t, json.Unmarshal([]byte(content), &entries))
+			require.Len(t, entries, 1)
+			entry = entries[0]
+		} else {
+			require.NoError(t, json.Unmarshal([]byte(content), &entry))
+		}
+
+		require.EqualValues(t, fileList[1], entry.Path)
+		require.EqualValues(t, fileList[1], entry.AbsolutePath)
+		require.EqualValues(t, 7, *entry.Size)
+		require.True(t, modTime.Equal(*entry.ModTime))
+		require.EqualValues(t, 2, entry.Index)
+		require.EqualValues(t, 2, entry.Total)
+	}
+}
+
+func TestOutputTemplate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goplaylist")
+	require.NoError(t, err)
+
+	defer os.RemoveAll(dir)
+
+	templateFile := filepath.Join(dir, "playlist.tmpl")
+	require.NoError(t, ioutil.WriteFile(templateFile, []byte("{{.Dir}} {{.Ext}} {{.Size}}\n"), 0o600))
+
+	fileList := []string{"/music/file_1.mp3", "/music/file_2.mp3"}
+
+	tt := []struct {
+		name   string
+		flags  []string
+		output string
+		err    string
+	}{
+		{
+			name: "OK_template",
+			flags: []string{"-template",
+				`{{define "header"}}# {{.Count}}/{{.Total}}\n{{end}}{{.Index}}/{{.Total}} {{.Base}}\n` +
+					`{{define "footer"}}# end\n{{end}}`},
+			output: "# 2/2\n1/2 file_1.mp3\n2/2 file_2.mp3\n# end\n",
+		},
+		{
+			name:   "OK_template_overrides_format",
+			flags:  []string{"-format", "json", "-template", `{{.Path}}\t{{.ModTime.IsZero}}\n`},
+			output: "/music/file_1.mp3\ttrue\n/music/file_2.mp3\ttrue\n",
+		},
+		{
+			name:   "OK_template_escape_inside_action",
+			flags:  []string{"-template", `{{printf "%s}}\t" .Base}}{{"\n"}}`},
+			output: "file_1.mp3}}\t\nfile_2.mp3}}\t\n",
+		},
+		{
+			name:   "OK_template_escape_outside_action",
+			flags:  []string{"-template", `{{printf "%s\n" .Base}}\t\\\n`},
+			output: "file_1.mp3\n\t\\\nfile_2.mp3\n\t\\\n",
+		},
+		{
+			name:   "OK_template_file",
+			flags:  []string{"-template_file", templateFile},
+			output: "/music .mp3 0\n/music .mp3 0\n",
+		},
+		{
+			name:  "FAIL_invalid_template",
+			flags: []string{"-template", "{{.Path"},
+			err:   `invalid value "{{.Path" for flag -template: invalid template: `,
+		},
+		{
+			name:  "FAIL_empty_template",
+			flags: []string{"-template", ""},
+			err:   `invalid value "" for flag -template: template is empty`,
+		},
+		{
+			name:  "FAIL_template_file_not_found",
+			flags: []string{"-template_file", filepath.Join(dir, "missing.tmpl")},
+			err:   `invalid value "` + filepath.Join(dir, "missing.tmpl") + `" for flag -template_file: `,
+		},
+	}
+
+	sortMode := playlist.FileSortMode(playlist.FileSortModeFileNameAsc)
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			var output bytes.Buffer
+
+			originalUsageOutput := usageOutput
+			usageOutput = ioutil.Discard
+
+			defer func() {
+				usageOutput = originalUsageOutput
+			}()
+
+			playlisterMock := playlisterMock{}
+			playlisterMock.Test(t)
+
+			if tc.err == "" {
+				playlisterMock.On("ListFilesFromPath", "2", []string{".ext"}, sortMode).Return(fileList, nil)
+			}
+
+			args := append(append([]string{"list"}, tc.flags...), "-sort_mode", "name", "-path", "2", "-extension", ".ext")
+
+			err := run(args, newPlaylisterMock(&playlisterMock), bufio.NewWriter(&output))
+			if tc.err != "" {
+				require.Error(t, err)
+				require.True(t, strings.HasPrefix(err.Error(), tc.err), err.Error())
+				require.EqualValues(t, _exitCodeUsage, exitCode(err))
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.output, output.String())
+			playlisterMock.AssertExpectations(t)
+		})
+	}
+}
+
+func TestOutputPathStyle(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goplaylist")
+	require.NoError(t, err)
+
+	defer os.RemoveAll(dir)
+
+	fileList := []string{filepath.Join(dir, "music", "Café #1.mp3")}
+	fileName := filepath.Join(dir, "playlists", "next.xspf")
+	require.NoError(t, os.Mkdir(filepath.Dir(fileName), 0o700))
+
+	sortMode := playlist.FileSortMode(playlist.FileSortModeFileNameAsc)
+
+	playlisterMock := playlisterMock{}
+	playlisterMock.Test(t)
+	playlisterMock.On("ListFilesFromPath", "2", []string{".mp3"}, sortMode).Return(fileList, nil)
+
+	args := []string{"list", "-format", "xspf", "-path_style", "relative", "-output", fileName,
+		"-sort_mode", "name", "-path", "2", "-extension", ".mp3"}
+
+	require.NoError(t, run(args, newPlaylisterMock(&playlisterMock), bufio.NewWriter(ioutil.Discard)))
+
+	content, err := ioutil.ReadFile(fileName)
+	require.NoError(t, err)
+	require.Contains(t, string(content), "<location>../music/Caf%C3%A9%20%231.mp3</location>")
+	require.Contains(t, string(content), "<title>Café #1</title>")
+
+	originalUsageOutput := usageOutput
+	usageOutput = ioutil.Discard
+
+	defer func() {
+		usageOutput = originalUsageOutput
+	}()
+
+	err = run([]string{"list", "-path_style", "unknown"}, newPlaylisterMock(&playlisterMock), nil)
+	require.EqualValues(t, _exitCodeUsage, exitCode(err))
+}
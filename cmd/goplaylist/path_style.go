@@ -0,0 +1,99 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	_pathStyleListed   = "listed"
+	_pathStyleRelative = "relative"
+	_pathStyleAbsolute = "absolute"
+	_pathStyleURI      = "uri"
+)
+
+var errUnknownPathStyle = errors.New("unknown path style")
+
+// pathStyleFlag is the path style flag value. It only accepts the path styles supported.
+type pathStyleFlag string
+
+func (f *pathStyleFlag) String() string {
+	return string(*f)
+}
+
+func (f *pathStyleFlag) Set(value string) error {
+	switch value {
+	case _pathStyleListed, _pathStyleRelative, _pathStyleAbsolute, _pathStyleURI:
+		*f = pathStyleFlag(value)
+		return nil
+	default:
+		return fmt.Errorf("%w: %s", errUnknownPathStyle, value)
+	}
+}
+
+// pathRenderer renders the file paths listed following a path style.
+type pathRenderer struct {
+	// style is the path style used to render the file paths.
+	style pathStyleFlag
+
+	// base is the directory the relative file paths are rendered from.
+	base string
+}
+
+// entry returns the playlist entry of the file path given rendering its paths.
+func (r pathRenderer) entry(file string) playlistEntry {
+	path, uri := r.render(file)
+	dir, _ := r.render(filepath.Dir(file))
+
+	return playlistEntry{file: file, path: path, uri: uri, dir: dir}
+}
+
+// render returns the file path given rendered following the path style and its URI reference.
+// The URI reference is relative for the relative path style and an absolute file URI otherwise.
+// If the file path can't be made relative to the base directory, it is rendered as absolute.
+func (r pathRenderer) render(file string) (path string, uri string) {
+	absoluteFile := absolutePath(file)
+
+	switch r.style {
+	case _pathStyleAbsolute:
+		return absoluteFile, fileURI(absoluteFile)
+	case _pathStyleURI:
+		return fileURI(absoluteFile), fileURI(absoluteFile)
+	case _pathStyleRelative:
+		relativeFile, err := filepath.Rel(absolutePath(r.base), absoluteFile)
+		if err != nil {
+			return absoluteFile, fileURI(absoluteFile)
+		}
+
+		return relativeFile, (&url.URL{Path: filepath.ToSlash(relativeFile)}).String()
+	default:
+		return file, fileURI(absoluteFile)
+	}
+}
+
+// absolutePath returns the absolute path of the path given from the current working directory.
+// If the current working directory can't be read, the path given is returned.
+func absolutePath(path string) string {
+	absolutePath, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+
+	return absolutePath
+}
+
+// fileURI returns the file URI of the file path given percent-encoding the characters not allowed.
+// The relative file paths are made absolute from the current working directory when it is possible.
+func fileURI(file string) string {
+	file = filepath.ToSlash(absolutePath(file))
+
+	// The Windows paths start with the volume name instead of a slash
+	if !strings.HasPrefix(file, "/") {
+		file = "/" + file
+	}
+
+	return (&url.URL{Scheme: "file", Path: file}).String()
+}
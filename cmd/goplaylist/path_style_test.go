@@ -0,0 +1,82 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPathRenderer(t *testing.T) {
+	workingDirectory, err := os.Getwd()
+	require.NoError(t, err)
+
+	tt := []struct {
+		name     string
+		renderer pathRenderer
+		file     string
+		path     string
+		uri      string
+	}{
+		{
+			name:     "OK_listed",
+			renderer: pathRenderer{style: _pathStyleListed},
+			file:     "music/Café #1.mp3",
+			path:     "music/Café #1.mp3",
+			uri:      "file://" + filepath.ToSlash(workingDirectory) + "/music/Caf%C3%A9%20%231.mp3",
+		},
+		{
+			name:     "OK_absolute",
+			renderer: pathRenderer{style: _pathStyleAbsolute},
+			file:     "music/song.mp3",
+			path:     filepath.Join(workingDirectory, "music", "song.mp3"),
+			uri:      "file://" + filepath.ToSlash(workingDirectory) + "/music/song.mp3",
+		},
+		{
+			name:     "OK_uri",
+			renderer: pathRenderer{style: _pathStyleURI},
+			file:     "/music/Café & 100%?.mp3",
+			path:     "file:///music/Caf%C3%A9%20&%20100%25%3F.mp3",
+			uri:      "file:///music/Caf%C3%A9%20&%20100%25%3F.mp3",
+		},
+		{
+			name:     "OK_relative_to_base",
+			renderer: pathRenderer{style: _pathStyleRelative, base: "/media/usb/playlists"},
+			file:     "/media/usb/music/Café #1.mp3",
+			path:     filepath.Join("..", "music", "Café #1.mp3"),
+			uri:      "../music/Caf%C3%A9%20%231.mp3",
+		},
+		{
+			name:     "OK_relative_to_working_directory",
+			renderer: pathRenderer{style: _pathStyleRelative},
+			file:     filepath.Join(workingDirectory, "music", "a:b.mp3"),
+			path:     filepath.Join("music", "a:b.mp3"),
+			uri:      "music/a:b.mp3",
+		},
+		{
+			name:     "OK_relative_colon_first_segment",
+			renderer: pathRenderer{style: _pathStyleRelative, base: "/music"},
+			file:     "/music/a:b.mp3",
+			path:     "a:b.mp3",
+			uri:      "./a:b.mp3",
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			path, uri := tc.renderer.render(tc.file)
+			require.Equal(t, tc.path, path)
+			require.Equal(t, tc.uri, uri)
+		})
+	}
+}
+
+func TestPathStyleFlag(t *testing.T) {
+	var style pathStyleFlag
+
+	require.NoError(t, style.Set("uri"))
+	require.EqualValues(t, _pathStyleURI, style)
+	require.EqualError(t, style.Set("unknown"), "unknown path style: unknown")
+}
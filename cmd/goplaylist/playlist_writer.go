@@ -0,0 +1,295 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/masch/goplaylist/internal/playlist"
+)
+
+const (
+	_formatQuoted = "quoted"
+	_formatM3U    = "m3u"
+	_formatM3U8   = "m3u8"
+	_formatPLS    = "pls"
+	_formatXSPF   = "xspf"
+	_formatWPL    = "wpl"
+	_formatJSON   = "json"
+	_formatNDJSON = "ndjson"
+	_formatShell  = "shell"
+	_formatNUL    = "nul"
+
+	// _latin1MaxRune is the greatest rune which can be encoded on ISO-8859-1.
+	_latin1MaxRune = 0xFF
+)
+
+// playlistEntry represents a file printed on a playlist.
+type playlistEntry struct {
+	// file is the file path as listed. It is used to read the file metadata.
+	file string
+
+	// path is the file path rendered following the path style.
+	path string
+
+	// uri is the file URI reference rendered following the path style.
+	uri string
+
+	// dir is the file directory rendered following the path style.
+	dir string
+
+	// index is the position of the file on the sorted file list starting from 1.
+	// It is zero when it is unknown.
+	index int
+
+	// total is the count of files of the sorted file list. It is zero when it is unknown.
+	total int
+}
+
+// A playlistWriter prints a file list on a playlist format.
+type playlistWriter interface {
+	// writePlaylist prints the entries given on the writer given and flushes it.
+	writePlaylist(entries []playlistEntry, writer writer) error
+}
+
+// An indexedPlaylistWriter is a playlistWriter which prints the position of the files on the sorted file list,
+// so the index and total of the entries must be filled.
+type indexedPlaylistWriter interface {
+	playlistWriter
+
+	// indexed reports whether the index and total of the entries are printed.
+	indexed() bool
+}
+
+// playlistWriters returns the playlist writers supported by their format name.
+func playlistWriters() map[string]playlistWriter {
+	return map[string]playlistWriter{
+		_formatQuoted: quotedWriter{},
+		_formatM3U:    m3uWriter{latin1: true},
+		_formatM3U8:   m3uWriter{},
+		_formatPLS:    plsWriter{},
+		_formatXSPF:   xspfWriter{},
+		_formatWPL:    wplWriter{},
+		_formatJSON:   jsonWriter{},
+		_formatNDJSON: jsonWriter{lines: true},
+		_formatShell:  shellWriter{},
+		_formatNUL:    nulWriter{},
+	}
+}
+
+// playlistFormats returns the format names of the playlist writers supported sorted alphabetically.
+func playlistFormats() []string {
+	var formats []string
+
+	for format := range playlistWriters() {
+		formats = append(formats, format)
+	}
+
+	sort.Strings(formats)
+
+	return formats
+}
+
+// writeEntries prints on the writer given the header given, the text returned by the function given
+// for each entry of the entries given and the footer given. Then it flushes the writer.
+// The empty header and footer are not printed.
+func writeEntries(writer writer, header string, entries []playlistEntry,
+	entryText func(i int, entry playlistEntry) string, footer string) error {
+	chunks := make([]string, 0, len(entries)+2)
+	chunks = append(chunks, header)
+
+	for i, entry := range entries {
+		chunks = append(chunks, entryText(i, entry))
+	}
+
+	chunks = append(chunks, footer)
+
+	for _, chunk := range chunks {
+		if chunk == "" {
+			continue
+		}
+
+		if _, err := writer.WriteString(chunk); err != nil {
+			return err
+		}
+	}
+
+	return writer.Flush()
+}
+
+// quotedWriter prints the file list with a blank space between them.
+// It also surrounds each item with a double quote ("") in order to deal with a file name
+// that contain spaces under the command-line in GNU/Linux.
+type quotedWriter struct{}
+
+func (quotedWriter) writePlaylist(entries []playlistEntry, writer writer) error {
+	return writeEntries(writer, "", entries, func(_ int, entry playlistEntry) string {
+		return `"` + entry.path + `" `
+	}, "")
+}
+
+// shellWriter prints the file list with a blank space between them quoting each item with single quotes,
+// so the output can be evaluated safely by a POSIX shell whatever characters the file names contain.
+type shellWriter struct{}
+
+func (shellWriter) writePlaylist(entries []playlistEntry, writer writer) error {
+	return writeEntries(writer, "", entries, func(_ int, entry playlistEntry) string {
+		return quoteShell(entry.path) + " "
+	}, "")
+}
+
+// nulWriter prints the file list terminating each item with a NUL character as xargs -0 expects.
+type nulWriter struct{}
+
+func (nulWriter) writePlaylist(entries []playlistEntry, writer writer) error {
+	return writeEntries(writer, "", entries, func(_ int, entry playlistEntry) string {
+		return entry.path + "\x00"
+	}, "")
+}
+
+// m3uWriter prints the file list as an extended M3U playlist.
+// The duration of the files is written in seconds, or -1 when it is unknown, and the title is the file name
+// without extension.
+type m3uWriter struct {
+	// latin1 defines whether the playlist is encoded on ISO-8859-1. The characters of the titles not supported
+	// are replaced by "?" and the paths which can't be encoded are written as percent-encoded file URIs,
+	// so they still locate the file. Otherwise, it is encoded on UTF-8.
+	latin1 bool
+}
+
+func (w m3uWriter) writePlaylist(entries []playlistEntry, writer writer) error {
+	return writeEntries(writer, "#EXTM3U\n", entries, func(_ int, entry playlistEntry) string {
+		title, path := fileTitle(entry.file), entry.path
+
+		if w.latin1 {
+			title = encodeLatin1(title)
+
+			if isLatin1(path) {
+				path = encodeLatin1(path)
+			} else {
+				path = fileURI(entry.file)
+			}
+		}
+
+		return fmt.Sprintf("#EXTINF:%d,%s\n%s\n", fileLength(entry.file), title, path)
+	}, "")
+}
+
+// plsWriter prints the file list as a PLS playlist version 2.
+// The duration of the files is written in seconds, or -1 when it is unknown, and the title is the file name
+// without extension.
+type plsWriter struct{}
+
+func (plsWriter) writePlaylist(entries []playlistEntry, writer writer) error {
+	return writeEntries(writer, "[playlist]\n", entries, func(i int, entry playlistEntry) string {
+		return fmt.Sprintf("File%[1]d=%[2]s\nTitle%[1]d=%[3]s\nLength%[1]d=%[4]d\n",
+			i+1, entry.path, fileTitle(entry.file), fileLength(entry.file))
+	}, fmt.Sprintf("NumberOfEntries=%d\nVersion=2\n", len(entries)))
+}
+
+// xspfWriter prints the file list as a XSPF playlist.
+// The files are located by their URI reference and the title is the file name without extension.
+type xspfWriter struct{}
+
+func (xspfWriter) writePlaylist(entries []playlistEntry, writer writer) error {
+	return writeEntries(writer,
+		"<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n"+
+			"<playlist version=\"1\" xmlns=\"http://xspf.org/ns/0/\">\n"+
+			"  <trackList>\n",
+		entries, func(_ int, entry playlistEntry) string {
+			return "    <track>\n" +
+				"      <location>" + escapeXML(entry.uri) + "</location>\n" +
+				"      <title>" + escapeXML(fileTitle(entry.file)) + "</title>\n" +
+				"    </track>\n"
+		},
+		"  </trackList>\n"+
+			"</playlist>\n")
+}
+
+// wplWriter prints the file list as a Windows Media Player playlist.
+// The files are located by their path.
+type wplWriter struct{}
+
+func (wplWriter) writePlaylist(entries []playlistEntry, writer writer) error {
+	return writeEntries(writer,
+		"<?wpl version=\"1.0\"?>\n"+
+			"<smil>\n"+
+			"  <head>\n"+
+			"    <meta name=\"Generator\" content=\"goplaylist\"/>\n"+
+			fmt.Sprintf("    <meta name=\"ItemCount\" content=\"%d\"/>\n", len(entries))+
+			"    <title>goplaylist</title>\n"+
+			"  </head>\n"+
+			"  <body>\n"+
+			"    <seq>\n",
+		entries, func(_ int, entry playlistEntry) string {
+			return "      <media src=\"" + escapeXML(entry.path) + "\"/>\n"
+		},
+		"    </seq>\n"+
+			"  </body>\n"+
+			"</smil>\n")
+}
+
+// quoteShell returns the string given quoted with single quotes following the POSIX shell rules.
+// Every single quote of the string is replaced by a closing quote, an escaped quote and an opening quote.
+func quoteShell(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// fileTitle returns the title of the file path given, that is, the file name without directory and extension.
+// The line breaks are replaced by spaces in order to keep the title on a single line.
+func fileTitle(file string) string {
+	title := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(title)
+}
+
+// fileLength returns the media duration of the file given rounded to whole seconds as the M3U and PLS playlists
+// expect. If the duration can't be read, -1 is returned.
+func fileLength(file string) int64 {
+	duration, err := playlist.ReadDuration(file)
+	if err != nil {
+		return -1
+	}
+
+	return int64(duration.Round(time.Second) / time.Second)
+}
+
+// escapeXML returns the string given escaped to be used as XML text or attribute value.
+func escapeXML(s string) string {
+	var escaped strings.Builder
+
+	// Writing on a strings.Builder never fails
+	_ = xml.EscapeText(&escaped, []byte(s))
+
+	return escaped.String()
+}
+
+// isLatin1 returns whether every character of the string given can be encoded on ISO-8859-1.
+func isLatin1(s string) bool {
+	for _, r := range s {
+		if r > _latin1MaxRune || r == utf8.RuneError {
+			return false
+		}
+	}
+
+	return true
+}
+
+// encodeLatin1 returns the string given encoded on ISO-8859-1 replacing the characters not supported by "?".
+func encodeLatin1(s string) string {
+	encoded := make([]byte, 0, len(s))
+
+	for _, r := range s {
+		if r > _latin1MaxRune || r == utf8.RuneError {
+			r = '?'
+		}
+
+		encoded = append(encoded, byte(r))
+	}
+
+	return string(encoded)
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+)
+
+// jsonEntry represents the file metadata printed by the JSON playlist writer.
+type jsonEntry struct {
+	// Path is the file path rendered following the path style.
+	Path string `json:"path"`
+
+	// AbsolutePath is the absolute file path.
+	AbsolutePath string `json:"absolute_path"`
+
+	// Size is the file size in bytes. It is omitted when the file can't be read.
+	Size *int64 `json:"size,omitempty"`
+
+	// ModTime is the file modification time. It is omitted when the file can't be read.
+	ModTime *time.Time `json:"mod_time,omitempty"`
+
+	// Index is the position of the file on the sorted file list starting from 1. It is omitted when it is unknown.
+	Index int `json:"index,omitempty"`
+
+	// Total is the count of files of the sorted file list. It is omitted when it is unknown.
+	Total int `json:"total,omitempty"`
+}
+
+// newJSONEntry returns the JSON entry of the playlist entry given reading the file metadata.
+func newJSONEntry(entry playlistEntry) jsonEntry {
+	jsonEntry := jsonEntry{
+		Path:         entry.path,
+		AbsolutePath: absolutePath(entry.file),
+		Index:        entry.index,
+		Total:        entry.total,
+	}
+
+	if f, err := os.Stat(entry.file); err == nil {
+		size, modTime := f.Size(), f.ModTime()
+		jsonEntry.Size, jsonEntry.ModTime = &size, &modTime
+	}
+
+	return jsonEntry
+}
+
+// jsonWriter prints the file list as a JSON array of objects with the file metadata.
+type jsonWriter struct {
+	// lines defines whether every object is printed on its own line without the array (NDJSON).
+	lines bool
+}
+
+func (jsonWriter) indexed() bool {
+	return true
+}
+
+func (w jsonWriter) writePlaylist(entries []playlistEntry, writer writer) error {
+	jsonEntries := make([]jsonEntry, 0, len(entries))
+
+	for _, entry := range entries {
+		jsonEntries = append(jsonEntries, newJSONEntry(entry))
+	}
+
+	if !w.lines {
+		return writeJSON(writer, jsonEntries)
+	}
+
+	for _, jsonEntry := range jsonEntries {
+		if err := writeJSON(writer, jsonEntry); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeJSON prints the value given encoded as JSON followed by a line break on the writer given and flushes it.
+func writeJSON(writer writer, v interface{}) error {
+	content, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	if _, err := writer.WriteString(string(content) + "\n"); err != nil {
+		return err
+	}
+
+	return writer.Flush()
+}
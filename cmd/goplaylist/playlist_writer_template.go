@@ -0,0 +1,227 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+)
+
+const (
+	// _templateHeader is the name of the template block printed before the files.
+	_templateHeader = "header"
+
+	// _templateFooter is the name of the template block printed after the files.
+	_templateFooter = "footer"
+
+	// _templateLeftDelim and _templateRightDelim are the delimiters of the template actions.
+	_templateLeftDelim  = "{{"
+	_templateRightDelim = "}}"
+)
+
+var errTemplateIsEmpty = errors.New("template is empty")
+
+// templateEntry represents the file fields available on the template of the template playlist writer.
+type templateEntry struct {
+	// Path is the file path rendered following the path style.
+	Path string
+
+	// Base is the file name without directory.
+	Base string
+
+	// Dir is the file directory rendered following the path style.
+	Dir string
+
+	// Ext is the file name extension including the dot.
+	Ext string
+
+	// Size is the file size in bytes. It is zero when the file can't be read.
+	Size int64
+
+	// ModTime is the file modification time. It is the zero time when the file can't be read.
+	ModTime time.Time
+
+	// Index is the position of the file on the sorted file list starting from 1. It is zero when it is unknown.
+	Index int
+
+	// Total is the count of files of the sorted file list. It is zero when it is unknown.
+	Total int
+}
+
+// templateSummary represents the fields available on the header and footer blocks of the template.
+type templateSummary struct {
+	// Count is the count of files printed.
+	Count int
+
+	// Total is the count of files of the sorted file list. It is zero when it is unknown.
+	Total int
+}
+
+// templateWriter prints every file of the file list rendering the template given with its fields.
+// The "header" and "footer" blocks defined on the template are rendered before and after the files.
+type templateWriter struct {
+	tmpl *template.Template
+}
+
+func (templateWriter) indexed() bool {
+	return true
+}
+
+func (w templateWriter) writePlaylist(entries []playlistEntry, writer writer) error {
+	summary := templateSummary{Count: len(entries)}
+
+	var rendered strings.Builder
+
+	if len(entries) > 0 {
+		summary.Total = entries[0].total
+	}
+
+	if err := w.executeBlock(&rendered, _templateHeader, summary); err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if err := w.tmpl.Execute(&rendered, newTemplateEntry(entry)); err != nil {
+			return err
+		}
+	}
+
+	if err := w.executeBlock(&rendered, _templateFooter, summary); err != nil {
+		return err
+	}
+
+	if _, err := writer.WriteString(rendered.String()); err != nil {
+		return err
+	}
+
+	return writer.Flush()
+}
+
+// executeBlock renders the template block of the name given if it is defined.
+func (w templateWriter) executeBlock(rendered *strings.Builder, name string, summary templateSummary) error {
+	if w.tmpl.Lookup(name) == nil {
+		return nil
+	}
+
+	return w.tmpl.ExecuteTemplate(rendered, name, summary)
+}
+
+// newTemplateEntry returns the template entry of the playlist entry given reading the file metadata.
+func newTemplateEntry(entry playlistEntry) templateEntry {
+	templateEntry := templateEntry{
+		Path:  entry.path,
+		Base:  filepath.Base(entry.file),
+		Dir:   entry.dir,
+		Ext:   filepath.Ext(entry.file),
+		Index: entry.index,
+		Total: entry.total,
+	}
+
+	if f, err := os.Stat(entry.file); err == nil {
+		templateEntry.Size, templateEntry.ModTime = f.Size(), f.ModTime()
+	}
+
+	return templateEntry
+}
+
+// templateFlag is the flag value which parses the template of the template playlist writer given.
+// The value is the template text or, if file is true, the template file name.
+type templateFlag struct {
+	writer *templateWriter
+	file   bool
+}
+
+func (f templateFlag) String() string {
+	return ""
+}
+
+func (f templateFlag) Set(value string) error {
+	text := value
+
+	if f.file {
+		content, err := ioutil.ReadFile(value)
+		if err != nil {
+			return err
+		}
+
+		text = string(content)
+	} else {
+		// The escape sequences are interpreted since they can't be typed on the command line easily
+		text = unescapeTemplate(text)
+	}
+
+	if text == "" {
+		return errTemplateIsEmpty
+	}
+
+	tmpl, err := template.New("goplaylist").Parse(text)
+	if err != nil {
+		return fmt.Errorf("invalid template: %w", err)
+	}
+
+	f.writer.tmpl = tmpl
+
+	return nil
+}
+
+// unescapeTemplate interprets the "\n", "\t" and "\\" escape sequences of the template text given placed outside of
+// its actions. The actions are kept untouched since their string literals interpret the escape sequences themselves.
+func unescapeTemplate(text string) string {
+	replacer := strings.NewReplacer(`\\`, `\`, `\n`, "\n", `\t`, "\t")
+
+	var unescaped strings.Builder
+
+	for {
+		start := strings.Index(text, _templateLeftDelim)
+		if start < 0 {
+			unescaped.WriteString(replacer.Replace(text))
+			return unescaped.String()
+		}
+
+		end := start + templateActionLength(text[start:])
+
+		unescaped.WriteString(replacer.Replace(text[:start]))
+		unescaped.WriteString(text[start:end])
+		text = text[end:]
+	}
+}
+
+// templateActionLength returns the length of the template action placed at the start of the text given
+// including its delimiters. The right delimiters placed on the quoted strings of the action are skipped.
+// If the action is not closed, the length of the whole text is returned.
+func templateActionLength(text string) int {
+	for i := len(_templateLeftDelim); i < len(text); i++ {
+		switch text[i] {
+		case '"', '\'', '`':
+			i = quotedEnd(text, i)
+		default:
+			if strings.HasPrefix(text[i:], _templateRightDelim) {
+				return i + len(_templateRightDelim)
+			}
+		}
+	}
+
+	return len(text)
+}
+
+// quotedEnd returns the position of the quote which closes the quoted string starting at the position given
+// of the text given. The quotes escaped by a backslash are skipped except on raw strings.
+// If the quoted string is not closed, the length of the text is returned.
+func quotedEnd(text string, start int) int {
+	quote := text[start]
+
+	for i := start + 1; i < len(text); i++ {
+		switch {
+		case text[i] == '\\' && quote != '`':
+			i++
+		case text[i] == quote:
+			return i
+		}
+	}
+
+	return len(text)
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var updateGolden = flag.Bool("update", false, "update the golden files") //nolint // test flag to regenerate golden files
+
+func TestPlaylistWriters(t *testing.T) {
+	var entries []playlistEntry
+
+	for _, file := range []string{
+		"/music/01 - Intro.mp3",
+		"/music/Café & Crème/<Live> \"Encore\".flac",
+		"/music/日本語 'single' 100% #1.ogg",
+	} {
+		entries = append(entries, pathRenderer{style: _pathStyleListed}.entry(file))
+	}
+
+	for _, format := range playlistFormats() {
+		format := format
+		t.Run(format, func(t *testing.T) {
+			var output bytes.Buffer
+
+			err := playlistWriters()[format].writePlaylist(entries, bufio.NewWriter(&output))
+			require.NoError(t, err)
+
+			golden := filepath.Join("testdata", "playlist."+format+".golden")
+
+			if *updateGolden {
+				require.NoError(t, ioutil.WriteFile(golden, output.Bytes(), 0o600))
+			}
+
+			expected, err := ioutil.ReadFile(golden)
+			require.NoError(t, err)
+			require.Equal(t, string(expected), output.String())
+		})
+	}
+}
+
+func TestPlaylistWritersDuration(t *testing.T) {
+	var entries []playlistEntry
+
+	// The files with duration are written with their length and the rest of files with -1
+	for _, file := range []string{
+		"../../internal/playlist/testdata/duration/xing.mp3",
+		"../../internal/playlist/testdata/duration/cbr.mp3",
+		"../../internal/playlist/testdata/duration/notes.mp3",
+		"/music/missing.mp3",
+	} {
+		entries = append(entries, pathRenderer{style: _pathStyleListed}.entry(file))
+	}
+
+	for _, format := range []string{_formatM3U8, _formatPLS} {
+		format := format
+		t.Run(format, func(t *testing.T) {
+			var output bytes.Buffer
+
+			err := playlistWriters()[format].writePlaylist(entries, bufio.NewWriter(&output))
+			require.NoError(t, err)
+
+			golden := filepath.Join("testdata", "playlist_duration."+format+".golden")
+
+			if *updateGolden {
+				require.NoError(t, ioutil.WriteFile(golden, output.Bytes(), 0o600))
+			}
+
+			expected, err := ioutil.ReadFile(golden)
+			require.NoError(t, err)
+			require.Equal(t, string(expected), output.String())
+		})
+	}
+}
+
+func TestPlaylistWritersEmpty(t *testing.T) {
+	for _, format := range playlistFormats() {
+		var output bytes.Buffer
+
+		err := playlistWriters()[format].writePlaylist(nil, bufio.NewWriter(&output))
+		require.NoError(t, err, format)
+	}
+}
+
+func TestShellWriterEvaluation(t *testing.T) {
+	shell, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("there is no POSIX shell available")
+	}
+
+	entries := []playlistEntry{
+		{path: `/downloads/"double" 'single'.mp3`},
+		{path: "/downloads/$(touch pwned) `touch pwned` $HOME.mp3"},
+		{path: "/downloads/line\nbreak\\.mp3"},
+	}
+
+	var output bytes.Buffer
+
+	require.NoError(t, shellWriter{}.writePlaylist(entries, bufio.NewWriter(&output)))
+
+	// The shell prints back every argument terminated by a NUL character
+	content, err := exec.Command(shell, "-c", `printf '%s\0' `+output.String()).Output()
+	require.NoError(t, err)
+
+	arguments := strings.Split(strings.TrimSuffix(string(content), "\x00"), "\x00")
+	require.Len(t, arguments, len(entries))
+
+	for i, entry := range entries {
+		require.Equal(t, entry.path, arguments[i])
+	}
+}
@@ -0,0 +1,15 @@
+//go:build !darwin && !windows && !(linux && (386 || amd64 || arm || arm64))
+// +build !darwin
+// +build !windows
+// +build !linux !386,!amd64,!arm,!arm64
+
+package playlist
+
+import (
+	"time"
+)
+
+// birthTime returns that the birth time is not available since it can't be read on this platform.
+func birthTime(string) (time.Time, bool) {
+	return time.Time{}, false
+}
@@ -0,0 +1,82 @@
+//go:build linux && (386 || amd64 || arm || arm64)
+// +build linux
+// +build 386 amd64 arm arm64
+
+package playlist
+
+import (
+	"runtime"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+const (
+	_atFDCWD           = -0x64
+	_atSymlinkNoFollow = 0x100
+	_statxBirthTime    = 0x800
+)
+
+// statxTimestamp is the timestamp of the statx structure (struct statx_timestamp).
+type statxTimestamp struct {
+	Sec  int64
+	Nsec uint32
+	_    int32
+}
+
+// statx is the file information returned by the statx system call (struct statx).
+type statx struct {
+	Mask           uint32
+	Blksize        uint32
+	Attributes     uint64
+	Nlink          uint32
+	UID            uint32
+	GID            uint32
+	Mode           uint16
+	_              uint16
+	Ino            uint64
+	Size           uint64
+	Blocks         uint64
+	AttributesMask uint64
+	Atime          statxTimestamp
+	Btime          statxTimestamp
+	Ctime          statxTimestamp
+	Mtime          statxTimestamp
+	_              [18]uint64
+}
+
+// statxTrap returns the statx system call number of the architecture running.
+func statxTrap() uintptr {
+	switch runtime.GOARCH {
+	case "386":
+		return 383
+	case "arm":
+		return 397
+	case "arm64":
+		return 291
+	default:
+		return 332
+	}
+}
+
+// birthTime returns the birth time of the file path given read by the statx system call and whether it is available.
+// The birth time is not available on kernels older than 4.11 or file systems which don't record it.
+func birthTime(filePath string) (time.Time, bool) {
+	path, err := syscall.BytePtrFromString(filePath)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	var (
+		info  statx
+		dirFD = _atFDCWD
+	)
+
+	_, _, errno := syscall.Syscall6(statxTrap(), uintptr(dirFD), uintptr(unsafe.Pointer(path)),
+		_atSymlinkNoFollow, _statxBirthTime, uintptr(unsafe.Pointer(&info)), 0)
+	if errno != 0 || info.Mask&_statxBirthTime == 0 {
+		return time.Time{}, false
+	}
+
+	return time.Unix(info.Btime.Sec, int64(info.Btime.Nsec)), true
+}
@@ -0,0 +1,22 @@
+package playlist
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// birthTime returns the birth (creation) time of the file path given and whether it is available.
+func birthTime(filePath string) (time.Time, bool) {
+	f, err := os.Lstat(filePath)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	attributes, ok := f.Sys().(*syscall.Win32FileAttributeData)
+	if !ok {
+		return time.Time{}, false
+	}
+
+	return time.Unix(0, attributes.CreationTime.Nanoseconds()), true
+}
@@ -0,0 +1,137 @@
+package playlist
+
+import (
+	"fmt"
+)
+
+var (
+	// ErrFileNotFound represent the error when a file given is not on the file list of the path.
+	ErrFileNotFound = fmt.Errorf("file not found")
+
+	// ErrIndexOutOfRange represent the error when a file index given is not on the file list of the path.
+	ErrIndexOutOfRange = fmt.Errorf("file index out of range")
+)
+
+// RewindFromPath moves back the last file name processed of the path given by the count given,
+// so the next call to GetNextFilesFromPath returns again the last count files returned.
+// If the count is greater than the files processed, it resumes from the first file.
+func (p *Playlist) RewindFromPath(path string, count int, fileExtension []string, sortMode SortOrder) error {
+	return p.moveLastFile(path, fileExtension, sortMode, func(fileList []string, position int) (int, error) {
+		position -= count
+		if position < -1 {
+			position = -1
+		}
+
+		return position, nil
+	})
+}
+
+// SeekFileFromPath moves the last file name processed of the path given to the file previous
+// to the file given, so the next call to GetNextFilesFromPath starts from the file given.
+// The file given must be on the file list of the path given.
+func (p *Playlist) SeekFileFromPath(path string, file string, fileExtension []string, sortMode SortOrder) error {
+	return p.moveLastFile(path, fileExtension, sortMode, func(fileList []string, _ int) (int, error) {
+		position := indexOfFile(fileList, file)
+		if position < 0 {
+			return 0, fmt.Errorf("%w: %s", ErrFileNotFound, file)
+		}
+
+		return position - 1, nil
+	})
+}
+
+// SeekIndexFromPath moves the last file name processed of the path given, so the next call
+// to GetNextFilesFromPath starts from the file of the index given. The index starts from 1.
+func (p *Playlist) SeekIndexFromPath(path string, index int, fileExtension []string, sortMode SortOrder) error {
+	return p.moveLastFile(path, fileExtension, sortMode, func(fileList []string, _ int) (int, error) {
+		if index < 1 || index > len(fileList) {
+			return 0, fmt.Errorf("%w: %d of %d files", ErrIndexOutOfRange, index, len(fileList))
+		}
+
+		return index - 2, nil
+	})
+}
+
+// ResetFromPath removes the state of the path given, so the next call to GetNextFilesFromPath
+// starts from the first file. If the shuffle seeds were chosen, the state moves to the next shuffle seed,
+// so the files are listed following a new permutation.
+func (p *Playlist) ResetFromPath(path string) error {
+	return p.stateStore().Update(path, func(state *State) error {
+		if state.ShuffleSeed == 0 {
+			*state = State{}
+			return nil
+		}
+
+		*state = State{ShuffleSeed: state.ShuffleSeed, NextShuffleSeed: state.NextShuffleSeed}
+		state.initShuffleSeeds(path)
+		state.rotateShuffleSeed()
+
+		return nil
+	})
+}
+
+// moveLastFile lists the files of the path given and moves the last file name processed to the position
+// returned by the move function given. The move function receives the file list and the position of
+// the last file name processed on it, being -1 when no file was processed yet. The files reserved are discarded.
+// The file list is shuffled by the shuffle seed of the state. If it is interleaved, the cursors are moved as if
+// the file list was returned until the new position.
+func (p *Playlist) moveLastFile(path string, fileExtension []string, sortMode SortOrder,
+	move func(fileList []string, position int) (int, error)) error {
+	listing, err := newFileListing(path, fileExtension, sortMode)
+	if err != nil {
+		return err
+	}
+
+	return p.stateStore().Update(path, func(state *State) error {
+		if listing.shuffled() {
+			state.initShuffleSeeds(path)
+		}
+
+		fileList := listing.sorted(state.ShuffleSeed)
+
+		position, err := move(fileList, listedPosition(path, fileList, *state, sortMode))
+		if err != nil {
+			return err
+		}
+
+		// The shuffle seeds are kept in order to move through the same permutations
+		*state = State{ShuffleSeed: state.ShuffleSeed, NextShuffleSeed: state.NextShuffleSeed}
+		hints := resumeHints(sortMode)
+
+		if listing.interleaved() {
+			setInterleavedPosition(path, fileList, position, hints, state)
+		}
+
+		if position >= 0 {
+			state.setLast(fileList[position], hints)
+		}
+
+		return nil
+	})
+}
+
+// listedPosition returns the position of the last file name processed of the state given of the path given
+// on the file list given as lastFilePosition does. If the sort order given interleaves the files,
+// it returns the count of files already returned minus one.
+func listedPosition(path string, fileList []string, state State, sortOrder SortOrder) int {
+	if isInterleaved(sortOrder) {
+		return len(interleavedHistory(path, fileList, state, sortOrder)) - 1
+	}
+
+	return lastFilePosition(fileList, state, sortOrder)
+}
+
+// lastFilePosition returns the position of the last file name processed of the state given on the file list given.
+// If no file was processed yet, it returns -1. If the last file doesn't exist anymore, it returns
+// the position previous to where it would have been sorted.
+func lastFilePosition(fileList []string, state State, sortMode SortOrder) int {
+	if state.Last == "" {
+		return -1
+	}
+
+	if position := indexOfFile(fileList, state.Last); position >= 0 {
+		return position
+	}
+
+	return findMissingFilePosition(fileList, state, sortMode) - 1
+}
@@ -0,0 +1,66 @@
+package playlist_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/masch/goplaylist/internal/playlist"
+)
+
+func TestPlaylistRewindSeekAndReset(t *testing.T) {
+	store := playlist.NewMemoryStateStore()
+	client := playlist.Playlist{Store: store}
+	extensions := []string{".ext"}
+
+	getNextFile := func() []string {
+		got, err := client.GetNextFilesFromPath("testdata/example_1", 1, extensions, playlist.FileSortModeFileNameAsc)
+		require.NoError(t, err)
+
+		return got
+	}
+
+	_, err := client.GetNextFilesFromPath("testdata/example_1", 4, extensions, playlist.FileSortModeFileNameAsc)
+	require.NoError(t, err)
+
+	// Rewind returns again the last files returned
+	require.NoError(t, client.RewindFromPath("testdata/example_1", 2, extensions, playlist.FileSortModeFileNameAsc))
+	require.EqualValues(t, []string{"testdata/example_1/dir_1/file_1_3.ext"}, getNextFile())
+
+	require.NoError(t, client.RewindFromPath("testdata/example_1", 10, extensions, playlist.FileSortModeFileNameAsc))
+	require.EqualValues(t, []string{"testdata/example_1/dir_1/file_1_1.ext"}, getNextFile())
+
+	// Seek starts from the file given
+	require.NoError(t, client.SeekFileFromPath("testdata/example_1", "testdata/example_1/dir_3/file_3_1.ext",
+		extensions, playlist.FileSortModeFileNameAsc))
+	require.EqualValues(t, []string{"testdata/example_1/dir_3/file_3_1.ext"}, getNextFile())
+
+	err = client.SeekFileFromPath("testdata/example_1", "testdata/example_1/dir_2/file_2_3.ext2",
+		extensions, playlist.FileSortModeFileNameAsc)
+	require.True(t, errors.Is(err, playlist.ErrFileNotFound))
+
+	require.NoError(t, client.SeekIndexFromPath("testdata/example_1", 1, extensions, playlist.FileSortModeFileNameAsc))
+	require.EqualValues(t, []string{"testdata/example_1/dir_1/file_1_1.ext"}, getNextFile())
+
+	require.NoError(t, client.SeekIndexFromPath("testdata/example_1", 5, extensions, playlist.FileSortModeFileNameAsc))
+	require.EqualValues(t, []string{"testdata/example_1/dir_2/file_2_2.ext"}, getNextFile())
+
+	for _, index := range []int{0, 8} {
+		err = client.SeekIndexFromPath("testdata/example_1", index, extensions, playlist.FileSortModeFileNameAsc)
+		require.True(t, errors.Is(err, playlist.ErrIndexOutOfRange))
+	}
+
+	// Rewind from a last file which doesn't exist anymore
+	require.NoError(t, store.Save("testdata/example_1", playlist.State{Last: "testdata/example_1/dir_2/file_2_15.ext"}))
+	require.NoError(t, client.RewindFromPath("testdata/example_1", 1, extensions, playlist.FileSortModeFileNameAsc))
+	require.EqualValues(t, []string{"testdata/example_1/dir_2/file_2_1.ext"}, getNextFile())
+
+	// Reset starts from the first file
+	require.NoError(t, client.ResetFromPath("testdata/example_1"))
+	require.EqualValues(t, []string{"testdata/example_1/dir_1/file_1_1.ext"}, getNextFile())
+
+	state, err := store.Load("testdata/example_1")
+	require.NoError(t, err)
+	require.EqualValues(t, "testdata/example_1/dir_1/file_1_1.ext", state.Last)
+}
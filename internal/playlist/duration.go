@@ -0,0 +1,241 @@
+package playlist
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	_flacBlockTypeStreamInfo     = 0
+	_flacStreamInfoLength        = 34
+	_wavHeaderLength             = 12
+	_wavChunkHeaderLength        = 8
+	_wavFormatMinLength          = 16
+	_wavFormatByteRateOffset     = 8
+	_wavChunkIDFormat            = "fmt "
+	_wavChunkIDData              = "data"
+	_mp4AtomTypeMovieHeader      = "mvhd"
+	_mp4MovieHeaderV0Length      = 16
+	_mp4MovieHeaderV1Length      = 28
+	_mp4MovieHeaderV0ScaleOffset = 8
+	_mp4MovieHeaderV1ScaleOffset = 16
+)
+
+var (
+	// ErrDurationNotFound represent the error when the duration of a file can't be read
+	// or its format is not supported.
+	ErrDurationNotFound = fmt.Errorf("duration not found")
+)
+
+// ReadDuration returns the media duration of the file path given. The MP3 files, read from their Xing or VBRI
+// header or by scanning their frames, the STREAMINFO block of FLAC files, the header of WAV files and
+// the movie header atom of MP4 files are supported, detecting the format by the file content.
+// If the duration can't be read, ErrDurationNotFound is returned.
+func ReadDuration(filePath string) (time.Duration, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	magic := make([]byte, _tagsMagicLength)
+	if _, err := io.ReadFull(file, magic); err != nil {
+		return 0, fmt.Errorf("%w: %s", ErrDurationNotFound, filePath)
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+
+	var duration time.Duration
+
+	switch {
+	case bytes.HasPrefix(magic, []byte("fLaC")):
+		duration, err = readFLACDuration(file)
+	case bytes.HasPrefix(magic, []byte("RIFF")) && bytes.Equal(magic[8:12], []byte("WAVE")):
+		duration, err = readWAVDuration(file)
+	case bytes.Equal(magic[4:8], []byte("ftyp")):
+		duration, err = readMP4Duration(file)
+	case bytes.HasPrefix(magic, []byte("ID3")) || isMPEGFrameSync(magic):
+		duration, err = readMP3Duration(file)
+	default:
+		return 0, fmt.Errorf("%w: %s", ErrDurationNotFound, filePath)
+	}
+
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s: %s", ErrDurationNotFound, filePath, err)
+	}
+
+	if duration <= 0 {
+		return 0, fmt.Errorf("%w: %s", ErrDurationNotFound, filePath)
+	}
+
+	return duration, nil
+}
+
+// compareDurations compares the files given by media duration.
+// The files with duration are sorted before the files without it, which are compared by file name.
+func compareDurations(a, b *sortFile) int {
+	durationA, okA := a.getDuration()
+	durationB, okB := b.getDuration()
+
+	switch {
+	case okA && !okB:
+		return -1
+	case !okA && okB:
+		return 1
+	case !okA && !okB:
+		return strings.Compare(a.path, b.path)
+	}
+
+	return compareInts(int64(durationA), int64(durationB))
+}
+
+// samplesDuration returns the duration of the count of samples given played at the sample rate given.
+// If the sample rate is zero, zero is returned.
+func samplesDuration(samples, sampleRate uint64) time.Duration {
+	if sampleRate == 0 {
+		return 0
+	}
+
+	// The whole seconds and the rest are converted apart in order to avoid overflowing
+	return time.Duration(samples/sampleRate)*time.Second +
+		time.Duration(samples%sampleRate)*time.Second/time.Duration(sampleRate)
+}
+
+// readFLACDuration returns the duration of the FLAC stream given from its STREAMINFO metadata block,
+// which is the first block and holds the sample rate on 20 bits and the total samples on 36 bits.
+func readFLACDuration(r io.Reader) (time.Duration, error) {
+	header := make([]byte, _flacMagicLength+_flacBlockHeaderLength)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return 0, err
+	}
+
+	if header[_flacMagicLength]&_flacBlockTypeMask != _flacBlockTypeStreamInfo {
+		return 0, fmt.Errorf("first FLAC metadata block is not STREAMINFO")
+	}
+
+	info := make([]byte, _flacStreamInfoLength)
+	if _, err := io.ReadFull(r, info); err != nil {
+		return 0, err
+	}
+
+	sampleRate := uint64(info[10])<<12 | uint64(info[11])<<4 | uint64(info[12])>>4
+	samples := uint64(info[13]&0x0f)<<32 | uint64(binary.BigEndian.Uint32(info[14:18]))
+
+	// The total samples are zero when the encoder didn't know them
+	return samplesDuration(samples, sampleRate), nil
+}
+
+// readWAVDuration returns the duration of the WAV file given dividing the size of its "data" chunk
+// by the byte rate of its "fmt " chunk. If the data size is unknown, the data extends to the end of the file.
+func readWAVDuration(r io.ReadSeeker) (time.Duration, error) {
+	end, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return 0, err
+	}
+
+	var byteRate uint64
+
+	header := make([]byte, _wavChunkHeaderLength)
+
+	for position := int64(_wavHeaderLength); position+_wavChunkHeaderLength <= end; {
+		if _, err := r.Seek(position, io.SeekStart); err != nil {
+			return 0, err
+		}
+
+		if _, err := io.ReadFull(r, header); err != nil {
+			return 0, err
+		}
+
+		id, size := string(header[:4]), int64(binary.LittleEndian.Uint32(header[4:]))
+		position += _wavChunkHeaderLength
+
+		switch id {
+		case _wavChunkIDFormat:
+			if size < _wavFormatMinLength {
+				return 0, io.ErrUnexpectedEOF
+			}
+
+			format := make([]byte, _wavFormatMinLength)
+			if _, err := io.ReadFull(r, format); err != nil {
+				return 0, err
+			}
+
+			byteRate = uint64(binary.LittleEndian.Uint32(format[_wavFormatByteRateOffset:]))
+		case _wavChunkIDData:
+			if byteRate == 0 {
+				return 0, fmt.Errorf("WAV data chunk before fmt chunk")
+			}
+
+			if size > end-position {
+				size = end - position
+			}
+
+			return samplesDuration(uint64(size), byteRate), nil
+		}
+
+		// The chunks are aligned to two bytes
+		position += size + size%2
+	}
+
+	return 0, fmt.Errorf("WAV data chunk not found")
+}
+
+// readMP4Duration returns the duration of the MP4 file given from its movie header atom, "moov.mvhd",
+// which holds the time scale and the duration on it.
+func readMP4Duration(r io.ReadSeeker) (time.Duration, error) {
+	end, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return 0, err
+	}
+
+	movieHeader, err := findMP4Atom(r, 0, end, _mp4AtomTypeMovie, _mp4AtomTypeMovieHeader)
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err := r.Seek(movieHeader.offset, io.SeekStart); err != nil {
+		return 0, err
+	}
+
+	// The version 1 uses 64 bits times and duration instead of 32 bits
+	content := make([]byte, _mp4FullAtomHeaderLength+_mp4MovieHeaderV1Length)
+	if int64(len(content)) > movieHeader.size {
+		content = content[:movieHeader.size]
+	}
+
+	if _, err := io.ReadFull(r, content); err != nil {
+		return 0, err
+	}
+
+	if len(content) < _mp4FullAtomHeaderLength+_mp4MovieHeaderV0Length {
+		return 0, io.ErrUnexpectedEOF
+	}
+
+	version, content := content[0], content[_mp4FullAtomHeaderLength:]
+
+	var timeScale, duration uint64
+
+	switch version {
+	case 0:
+		timeScale = uint64(binary.BigEndian.Uint32(content[_mp4MovieHeaderV0ScaleOffset:]))
+		duration = uint64(binary.BigEndian.Uint32(content[_mp4MovieHeaderV0ScaleOffset+4:]))
+	case 1:
+		if len(content) < _mp4MovieHeaderV1Length {
+			return 0, io.ErrUnexpectedEOF
+		}
+
+		timeScale = uint64(binary.BigEndian.Uint32(content[_mp4MovieHeaderV1ScaleOffset:]))
+		duration = binary.BigEndian.Uint64(content[_mp4MovieHeaderV1ScaleOffset+4:])
+	default:
+		return 0, fmt.Errorf("unsupported MP4 movie header version %d", version)
+	}
+
+	return samplesDuration(duration, timeScale), nil
+}
@@ -0,0 +1,252 @@
+package playlist
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"io/ioutil"
+	"time"
+)
+
+const (
+	_mpegHeaderLength      = 4
+	_mpegMaxSyncSearch     = 64 << 10
+	_mpegReaderSize        = 8 << 10
+	_mpegVersion25         = 0
+	_mpegVersion2          = 2
+	_mpegVersion1          = 3
+	_mpegVersionReserved   = 1
+	_mpegLayerReserved     = 0
+	_mpegLayer3            = 1
+	_mpegLayer1            = 3
+	_mpegChannelModeMono   = 3
+	_mpegBitrateIndexFree  = 0
+	_mpegBitrateIndexBad   = 15
+	_mpegSampleRateBad     = 3
+	_mpegVBRIOffset        = _mpegHeaderLength + 32
+	_mpegVBRIFramesOffset  = 14
+	_mpegXingFlagFrames    = 0x01
+	_mpegXingFlagsLength   = 4
+	_mpegXingFramesLength  = 4
+	_id3FlagFooter         = 0x10
+	_id3FooterLength       = 10
+	_mpegSamplesPerFrameL1 = 384
+	_mpegSamplesPerFrame   = 1152
+	_mpegLayer1SlotLength  = 4
+)
+
+// _mpegBitrates are the bitrates in kbit/s of every bitrate index by MPEG version, MPEG-1 or MPEG-2 and 2.5,
+// and by layer, from layer I to layer III. The free and bad indexes are not supported.
+var _mpegBitrates = [2][3][15]int{
+	{
+		{0, 32, 64, 96, 128, 160, 192, 224, 256, 288, 320, 352, 384, 416, 448},
+		{0, 32, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 384},
+		{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320},
+	},
+	{
+		{0, 32, 48, 56, 64, 80, 96, 112, 128, 144, 160, 176, 192, 224, 256},
+		{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160},
+		{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160},
+	},
+}
+
+// _mpegSampleRates are the sample rates in Hz of every sample rate index by MPEG version bits.
+var _mpegSampleRates = [4][3]int{
+	_mpegVersion25:       {11025, 12000, 8000},
+	_mpegVersionReserved: {},
+	_mpegVersion2:        {22050, 24000, 16000},
+	_mpegVersion1:        {44100, 48000, 32000},
+}
+
+// errMPEGFrameNotFound represents the error when no MPEG audio frame is found on a stream.
+var errMPEGFrameNotFound = errors.New("mpeg audio frame not found")
+
+// mpegFrame represents the header of a MPEG audio frame.
+type mpegFrame struct {
+	version    byte
+	layer      byte
+	mono       bool
+	sampleRate int
+	samples    int
+	length     int
+}
+
+// parseMPEGFrame parses the MPEG audio frame header given, which starts with 11 sync bits.
+// If it is not a valid header, it returns false.
+func parseMPEGFrame(header []byte) (mpegFrame, bool) {
+	if !isMPEGFrameSync(header) {
+		return mpegFrame{}, false
+	}
+
+	version, layer := header[1]>>3&0x03, header[1]>>1&0x03
+	bitrateIndex, sampleRateIndex, padding := header[2]>>4, header[2]>>2&0x03, int(header[2]>>1&0x01)
+
+	if version == _mpegVersionReserved || layer == _mpegLayerReserved || sampleRateIndex == _mpegSampleRateBad ||
+		bitrateIndex == _mpegBitrateIndexFree || bitrateIndex == _mpegBitrateIndexBad {
+		return mpegFrame{}, false
+	}
+
+	versionClass := 1
+	if version == _mpegVersion1 {
+		versionClass = 0
+	}
+
+	frame := mpegFrame{
+		version:    version,
+		layer:      layer,
+		mono:       header[3]>>6 == _mpegChannelModeMono,
+		sampleRate: _mpegSampleRates[version][sampleRateIndex],
+	}
+	bitrate := _mpegBitrates[versionClass][_mpegLayer1-layer][bitrateIndex] * 1000
+
+	switch {
+	case layer == _mpegLayer1:
+		frame.samples = _mpegSamplesPerFrameL1
+		frame.length = (frame.samples/8/_mpegLayer1SlotLength*bitrate/frame.sampleRate + padding) * _mpegLayer1SlotLength
+	case layer == _mpegLayer3 && version != _mpegVersion1:
+		frame.samples = _mpegSamplesPerFrame / 2
+		frame.length = frame.samples/8*bitrate/frame.sampleRate + padding
+	default:
+		frame.samples = _mpegSamplesPerFrame
+		frame.length = frame.samples/8*bitrate/frame.sampleRate + padding
+	}
+
+	return frame, frame.length > _mpegHeaderLength
+}
+
+// isMPEGFrameSync returns whether the data given starts with the 11 sync bits of a MPEG audio frame header.
+func isMPEGFrameSync(data []byte) bool {
+	return len(data) >= _mpegHeaderLength && data[0] == 0xff && data[1]&0xe0 == 0xe0
+}
+
+// readMP3Duration returns the duration of the MP3 stream given. The frame count is read from the Xing or VBRI
+// header of the first frame when there is one. Otherwise, the frames are scanned until the end of the stream
+// or the first data which is not a frame, such as an ID3v1 tag.
+func readMP3Duration(r io.Reader) (time.Duration, error) {
+	reader := bufio.NewReaderSize(r, _mpegReaderSize)
+
+	if err := skipID3v2Tags(reader); err != nil {
+		return 0, err
+	}
+
+	first, err := findMPEGFrame(reader)
+	if err != nil {
+		return 0, err
+	}
+
+	// The first frame can be cut at the end of the stream, so the data available is searched
+	data, _ := reader.Peek(first.length)
+	if frames, ok := mpegHeaderFrames(first, data); ok {
+		return samplesDuration(uint64(frames)*uint64(first.samples), uint64(first.sampleRate)), nil
+	}
+
+	var samples uint64
+
+	for {
+		header, err := reader.Peek(_mpegHeaderLength)
+		if err != nil {
+			break
+		}
+
+		frame, ok := parseMPEGFrame(header)
+		if !ok {
+			break
+		}
+
+		// The frames cut at the end of the stream are not played
+		if _, err := reader.Discard(frame.length); err != nil {
+			break
+		}
+
+		samples += uint64(frame.samples)
+	}
+
+	return samplesDuration(samples, uint64(first.sampleRate)), nil
+}
+
+// skipID3v2Tags discards the ID3v2 tags placed at the start of the reader given.
+func skipID3v2Tags(r *bufio.Reader) error {
+	for {
+		header, err := r.Peek(_id3HeaderLength)
+		if err != nil || !bytes.HasPrefix(header, []byte("ID3")) {
+			return nil
+		}
+
+		length := int64(_id3HeaderLength + syncsafeInt(header[6:10]))
+		if header[5]&_id3FlagFooter != 0 {
+			length += _id3FooterLength
+		}
+
+		if _, err := io.CopyN(ioutil.Discard, r, length); err != nil {
+			return err
+		}
+	}
+}
+
+// findMPEGFrame discards the data of the reader given until the first MPEG audio frame and returns its header.
+// A frame is found when its header is valid and it is followed by another valid header or by the end of the stream,
+// so the sync bits found on other data are skipped.
+func findMPEGFrame(r *bufio.Reader) (mpegFrame, error) {
+	for i := 0; i < _mpegMaxSyncSearch; i++ {
+		header, err := r.Peek(_mpegHeaderLength)
+		if err != nil {
+			return mpegFrame{}, errMPEGFrameNotFound
+		}
+
+		if frame, ok := parseMPEGFrame(header); ok {
+			data, err := r.Peek(frame.length + _mpegHeaderLength)
+			if err != nil {
+				return frame, nil
+			}
+
+			if next, ok := parseMPEGFrame(data[frame.length:]); ok && next.sampleRate == frame.sampleRate {
+				return frame, nil
+			}
+		}
+
+		if _, err := r.Discard(1); err != nil {
+			return mpegFrame{}, err
+		}
+	}
+
+	return mpegFrame{}, errMPEGFrameNotFound
+}
+
+// mpegHeaderFrames returns the frame count of the Xing, also named Info, or VBRI header placed on the data of
+// the first frame given. The Xing header is placed after the side information, whose length depends on
+// the MPEG version and the channel mode, and the VBRI header is placed 32 bytes after the frame header.
+func mpegHeaderFrames(frame mpegFrame, data []byte) (uint32, bool) {
+	sideInformationLength := 32
+
+	switch {
+	case frame.version == _mpegVersion1 && frame.mono:
+		sideInformationLength = 17
+	case frame.version != _mpegVersion1 && frame.mono:
+		sideInformationLength = 9
+	case frame.version != _mpegVersion1:
+		sideInformationLength = 17
+	}
+
+	if xing := _mpegHeaderLength + sideInformationLength; len(data) >= xing+4+_mpegXingFlagsLength {
+		tag := data[xing:]
+		if bytes.HasPrefix(tag, []byte("Xing")) || bytes.HasPrefix(tag, []byte("Info")) {
+			flags := binary.BigEndian.Uint32(tag[4:])
+			fields := tag[4+_mpegXingFlagsLength:]
+
+			if flags&_mpegXingFlagFrames != 0 && len(fields) >= _mpegXingFramesLength {
+				return binary.BigEndian.Uint32(fields), true
+			}
+		}
+	}
+
+	if len(data) >= _mpegVBRIOffset+_mpegVBRIFramesOffset+4 {
+		tag := data[_mpegVBRIOffset:]
+		if bytes.HasPrefix(tag, []byte("VBRI")) {
+			return binary.BigEndian.Uint32(tag[_mpegVBRIFramesOffset:]), true
+		}
+	}
+
+	return 0, false
+}
@@ -0,0 +1,140 @@
+package playlist_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/masch/goplaylist/internal/playlist"
+)
+
+func TestReadDuration(t *testing.T) {
+	tt := []struct {
+		fileName string
+		expected time.Duration
+		err      error
+	}{
+		{fileName: "testdata/duration/short.wav", expected: 500 * time.Millisecond},
+		{fileName: "testdata/duration/cbr.mp3", expected: 2016 * time.Millisecond},
+		{fileName: "testdata/duration/xing.mp3", expected: 26122448979},
+		{fileName: "testdata/tags/id3v23.mp3", expected: 26122448},
+		{fileName: "testdata/tags/untagged.mp3", expected: 52244897},
+		{fileName: "testdata/tags/vorbis.flac", expected: 10 * time.Second},
+		{fileName: "testdata/tags/itunes.m4a", expected: 10 * time.Second},
+		{fileName: "testdata/tags/vorbis.ogg", err: playlist.ErrDurationNotFound},
+		{fileName: "testdata/duration/notes.mp3", err: playlist.ErrDurationNotFound},
+		{fileName: "testdata/duration/missing.mp3", err: os.ErrNotExist},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.fileName, func(t *testing.T) {
+			got, err := playlist.ReadDuration(tc.fileName)
+			require.True(t, errors.Is(err, tc.err), err)
+			require.EqualValues(t, tc.expected, got)
+		})
+	}
+}
+
+func TestListFilesFromPathBySizeAndDuration(t *testing.T) {
+	client := playlist.Playlist{Store: playlist.NewMemoryStateStore()}
+	extensions := []string{".mp3", ".wav"}
+
+	got, err := client.ListFilesFromPath("testdata/duration", extensions, playlist.FileSortModeDurationAsc)
+	require.NoError(t, err)
+	require.EqualValues(t, []string{
+		"testdata/duration/short.wav",
+		"testdata/duration/cbr.mp3",
+		"testdata/duration/xing.mp3",
+		"testdata/duration/notes.mp3",
+	}, got)
+
+	got, err = client.ListFilesFromPath("testdata/duration", extensions, playlist.FileSortModeSizeAsc)
+	require.NoError(t, err)
+	require.EqualValues(t, []string{
+		"testdata/duration/notes.mp3",
+		"testdata/duration/xing.mp3",
+		"testdata/duration/cbr.mp3",
+		"testdata/duration/short.wav",
+	}, got)
+
+	got, err = client.ListFilesFromPath("testdata/duration", extensions,
+		playlist.SortSpec{{Mode: playlist.FileSortModeSizeAsc, Descending: true}})
+	require.NoError(t, err)
+	require.EqualValues(t, []string{
+		"testdata/duration/short.wav",
+		"testdata/duration/cbr.mp3",
+		"testdata/duration/xing.mp3",
+		"testdata/duration/notes.mp3",
+	}, got)
+}
+
+func TestPlaylistResumeFromMissingLastFileBySizeAndDuration(t *testing.T) {
+	tt := []struct {
+		name     string
+		sortMode playlist.FileSortMode
+		listed   []string
+		next     string
+		hints    playlist.ResumeHints
+	}{
+		{
+			name:     "duration",
+			sortMode: playlist.FileSortModeDurationAsc,
+			listed:   []string{"short.wav", "cbr.mp3"},
+			next:     "xing.mp3",
+			hints:    playlist.ResumeHintDuration,
+		},
+		{
+			name:     "size",
+			sortMode: playlist.FileSortModeSizeAsc,
+			listed:   []string{"notes.mp3", "xing.mp3"},
+			next:     "cbr.mp3",
+			hints:    playlist.ResumeHintSize,
+		},
+		{
+			name:     "name",
+			sortMode: playlist.FileSortModeFileNameAsc,
+			listed:   []string{"cbr.mp3", "notes.mp3"},
+			next:     "short.wav",
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			directory, clearFunc := createTemporaryDirectory(t)
+			defer clearFunc()
+
+			copyTestdataFiles(t, "testdata/duration", directory)
+
+			extensions := []string{".mp3", ".wav"}
+			store := playlist.NewMemoryStateStore()
+			client := playlist.Playlist{Store: store}
+
+			got, err := client.GetNextFilesFromPath(directory, 2, extensions, tc.sortMode)
+			require.NoError(t, err)
+			require.EqualValues(t, []string{
+				filepath.Join(directory, tc.listed[0]),
+				filepath.Join(directory, tc.listed[1]),
+			}, got)
+
+			// Only the values compared by the sort mode are read
+			state, err := store.Load(directory)
+			require.NoError(t, err)
+			require.EqualValues(t, tc.hints, state.ResumeHints)
+			require.Equal(t, tc.hints&playlist.ResumeHintSize != 0, state.LastSize != 0)
+			require.Equal(t, tc.hints&playlist.ResumeHintDuration != 0, state.LastDuration != 0)
+
+			// The last file is deleted, so it resumes after its size, duration or name
+			require.NoError(t, os.Remove(filepath.Join(directory, tc.listed[1])))
+
+			got, err = client.GetNextFilesFromPath(directory, 1, extensions, tc.sortMode)
+			require.NoError(t, err)
+			require.EqualValues(t, []string{filepath.Join(directory, tc.next)}, got)
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package playlist
+
+import (
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Episode represents the season and episode number parsed from a file name.
+type Episode struct {
+	// Season is the season number. It is zero when the file name only has an absolute episode number.
+	Season int
+
+	// Number is the episode number on the season or the absolute episode number.
+	Number int
+}
+
+var (
+	// _seasonEpisodeRegexp matches the "S02E05", "s2.e5" and "S02 E05" patterns.
+	_seasonEpisodeRegexp = regexp.MustCompile(`(?:^|[^a-z0-9])s(\d{1,4})[ ._-]*e(\d{1,4})(?:[^0-9]|$)`)
+
+	// _crossEpisodeRegexp matches the "2x05" pattern. The episode has at least two digits,
+	// so resolutions such as "1920x1080" are not matched.
+	_crossEpisodeRegexp = regexp.MustCompile(`(?:^|[^a-z0-9])(\d{1,2})x(\d{2,3})(?:[^a-z0-9]|$)`)
+
+	// _seasonWordEpisodeRegexp matches the "Season 2 Episode 5" pattern.
+	_seasonWordEpisodeRegexp = regexp.MustCompile(
+		`(?:^|[^a-z0-9])season[ ._-]*(\d{1,4})[ ._-]*(?:episode|ep|e)[ ._-]*(\d{1,4})(?:[^0-9]|$)`)
+
+	// _absoluteEpisodeRegexp matches the "Episode 5", "Ep05", "E05", "Part 5" and "Chapter 5" patterns.
+	_absoluteEpisodeRegexp = regexp.MustCompile(
+		`(?:^|[^a-z0-9])(?:episode|ep|e|part|pt|chapter|ch)[ ._-]*(\d{1,4})(?:[^0-9]|$)`)
+
+	// _romanEpisodeRegexp matches the "Part V", "Episode IV" and "Chapter XII" patterns.
+	_romanEpisodeRegexp = regexp.MustCompile(
+		`(?:^|[^a-z0-9])(?:episode|part|chapter)[ ._-]*([ivxlcdm]+)(?:[^a-z0-9]|$)`)
+
+	// _dashEpisodeRegexp matches the "Show - 012" pattern used by absolute numbered releases.
+	_dashEpisodeRegexp = regexp.MustCompile(`\s-\s(\d{1,4})(?:[^0-9]|$)`)
+)
+
+// ParseEpisode returns the episode parsed from the base name of the file path given and whether it was found.
+// The "S02E05", "2x05" and "Season 2 Episode 5" patterns define the season, while the "Episode 5", "E05",
+// "Part V", "Chapter 12" and "Show - 012" patterns define an absolute episode number. The roman numerals are only
+// parsed after the "episode", "part" and "chapter" words. The patterns are matched case-insensitively.
+func ParseEpisode(filePath string) (Episode, bool) {
+	name := strings.ToLower(strings.TrimSuffix(filepath.Base(filePath), filepath.Ext(filePath)))
+
+	for _, re := range []*regexp.Regexp{_seasonEpisodeRegexp, _crossEpisodeRegexp, _seasonWordEpisodeRegexp} {
+		if match := re.FindStringSubmatch(name); match != nil {
+			return Episode{Season: atoi(match[1]), Number: atoi(match[2])}, true
+		}
+	}
+
+	for _, re := range []*regexp.Regexp{_absoluteEpisodeRegexp, _dashEpisodeRegexp} {
+		if match := re.FindStringSubmatch(name); match != nil {
+			return Episode{Number: atoi(match[1])}, true
+		}
+	}
+
+	if match := _romanEpisodeRegexp.FindStringSubmatch(name); match != nil {
+		if number, ok := parseRoman(match[1]); ok {
+			return Episode{Number: number}, true
+		}
+	}
+
+	return Episode{}, false
+}
+
+// compareEpisodes compares the file paths given by their directory following the natural order and then
+// by the episode parsed from their names. The files with an episode are sorted before the files without it,
+// and the files with the same episode or without it are compared following the natural order.
+func compareEpisodes(a, b *sortFile) int {
+	if result := compareNatural(filepath.Dir(a.path), filepath.Dir(b.path)); result != 0 {
+		return result
+	}
+
+	episodeA, okA := a.getEpisode()
+	episodeB, okB := b.getEpisode()
+
+	switch {
+	case okA && !okB:
+		return -1
+	case !okA && okB:
+		return 1
+	case okA && okB && episodeA.Season != episodeB.Season:
+		return episodeA.Season - episodeB.Season
+	case okA && okB && episodeA.Number != episodeB.Number:
+		return episodeA.Number - episodeB.Number
+	default:
+		return compareNatural(a.path, b.path)
+	}
+}
+
+// _romanNumerals are the roman numerals from the greatest value including the subtractive pairs.
+var _romanNumerals = []struct {
+	symbol string
+	value  int
+}{
+	{"m", 1000}, {"cm", 900}, {"d", 500}, {"cd", 400}, {"c", 100}, {"xc", 90},
+	{"l", 50}, {"xl", 40}, {"x", 10}, {"ix", 9}, {"v", 5}, {"iv", 4}, {"i", 1},
+}
+
+// _romanMaxValue is the greatest roman numeral parsed, so words made of roman letters such as "mix" are not parsed.
+const _romanMaxValue = 99
+
+// parseRoman returns the value of the lower case roman numeral given and whether it is well formed.
+func parseRoman(numeral string) (int, bool) {
+	value, rest := 0, numeral
+
+	for _, roman := range _romanNumerals {
+		for strings.HasPrefix(rest, roman.symbol) {
+			value += roman.value
+			rest = rest[len(roman.symbol):]
+		}
+	}
+
+	// The numerals not written on their canonical form, such as "iiii" or "ixi", are rejected
+	if rest != "" || value == 0 || value > _romanMaxValue || formatRoman(value) != numeral {
+		return 0, false
+	}
+
+	return value, true
+}
+
+// formatRoman returns the lower case roman numeral of the value given.
+func formatRoman(value int) string {
+	var numeral strings.Builder
+
+	for _, roman := range _romanNumerals {
+		for value >= roman.value {
+			numeral.WriteString(roman.symbol)
+			value -= roman.value
+		}
+	}
+
+	return numeral.String()
+}
+
+// atoi returns the value of the digit run given. The patterns limit the digit runs, so they never overflow.
+func atoi(digits string) int {
+	value, _ := strconv.Atoi(digits)
+	return value
+}
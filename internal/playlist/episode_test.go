@@ -0,0 +1,109 @@
+package playlist_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/masch/goplaylist/internal/playlist"
+)
+
+func TestParseEpisode(t *testing.T) {
+	tt := []struct {
+		fileName string
+		expected playlist.Episode
+		found    bool
+	}{
+		{fileName: "Show.S02E05.720p.mkv", expected: playlist.Episode{Season: 2, Number: 5}, found: true},
+		{fileName: "show.s2.e5.mkv", expected: playlist.Episode{Season: 2, Number: 5}, found: true},
+		{fileName: "Show S02 E05 Title.mkv", expected: playlist.Episode{Season: 2, Number: 5}, found: true},
+		{fileName: "Show_S10E105_Title.mkv", expected: playlist.Episode{Season: 10, Number: 105}, found: true},
+		{fileName: "Show.S01E01E02.mkv", expected: playlist.Episode{Season: 1, Number: 1}, found: true},
+		{fileName: "Show 2x05.mkv", expected: playlist.Episode{Season: 2, Number: 5}, found: true},
+		{fileName: "Show - 12x101 - Title.mkv", expected: playlist.Episode{Season: 12, Number: 101}, found: true},
+		{fileName: "Season 2 Episode 5.mkv", expected: playlist.Episode{Season: 2, Number: 5}, found: true},
+		{fileName: "show_season_2_ep_5.mkv", expected: playlist.Episode{Season: 2, Number: 5}, found: true},
+		{fileName: "Episode 5.mkv", expected: playlist.Episode{Number: 5}, found: true},
+		{fileName: "Show Ep05.mkv", expected: playlist.Episode{Number: 5}, found: true},
+		{fileName: "Show.E105.mkv", expected: playlist.Episode{Number: 105}, found: true},
+		{fileName: "Course - Part 3.mp4", expected: playlist.Episode{Number: 3}, found: true},
+		{fileName: "Book Chapter 12.mp3", expected: playlist.Episode{Number: 12}, found: true},
+		{fileName: "[Group] Show - 012 [1080p].mkv", expected: playlist.Episode{Number: 12}, found: true},
+		{fileName: "Show - 1000v2.mkv", expected: playlist.Episode{Number: 1000}, found: true},
+		{fileName: "Part V.mkv", expected: playlist.Episode{Number: 5}, found: true},
+		{fileName: "Movie Part IV.mkv", expected: playlist.Episode{Number: 4}, found: true},
+		{fileName: "episode xiv - title.mkv", expected: playlist.Episode{Number: 14}, found: true},
+		{fileName: "Chapter XCIX.mp3", expected: playlist.Episode{Number: 99}, found: true},
+		{fileName: "/series/Season 3/Show.S03E07.mkv", expected: playlist.Episode{Season: 3, Number: 7}, found: true},
+		{fileName: "Show.1920x1080.mkv"},
+		{fileName: "Part Mix.mkv"},
+		{fileName: "Part IIII.mkv"},
+		{fileName: "Part C.mkv"},
+		{fileName: "Party 5.mkv"},
+		{fileName: "Deep 5.mkv"},
+		{fileName: "Show.mkv"},
+		{fileName: "lecture-2021-03-04.mp4"},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.fileName, func(t *testing.T) {
+			got, found := playlist.ParseEpisode(tc.fileName)
+			require.EqualValues(t, tc.found, found)
+			require.EqualValues(t, tc.expected, got)
+		})
+	}
+}
+
+func TestListFilesFromPathByEpisode(t *testing.T) {
+	directory, err := ioutil.TempDir("", "goplaylist")
+	require.NoError(t, err)
+
+	defer func() {
+		require.NoError(t, os.RemoveAll(directory))
+	}()
+
+	require.NoError(t, os.Mkdir(filepath.Join(directory, "Show"), 0o700))
+	require.NoError(t, os.Mkdir(filepath.Join(directory, "Movie"), 0o700))
+
+	expected := []string{
+		"Movie/Part I.mkv",
+		"Movie/Part II.mkv",
+		"Movie/Part 3.mkv",
+		"Movie/Part IV.mkv",
+		"Movie/Bonus.mkv",
+		"Show/Show.S01E02.mkv",
+		"Show/show 1x10.mkv",
+		"Show/Show.S02E01.mkv",
+		"Show/Show 2x02.mkv",
+		"Show/Show Season 2 Episode 3.mkv",
+		"Show/extras 1.mkv",
+		"Show/extras 2.mkv",
+	}
+
+	// The files are created on reverse order, so the creation doesn't define the order
+	for i := len(expected) - 1; i >= 0; i-- {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(directory, expected[i]), nil, 0o600))
+		expected[i] = filepath.Join(directory, expected[i])
+	}
+
+	client := playlist.Playlist{Store: playlist.NewMemoryStateStore()}
+
+	got, err := client.ListFilesFromPath(directory, []string{".mkv"}, playlist.FileSortModeEpisodeAsc)
+	require.NoError(t, err)
+	require.EqualValues(t, expected, got)
+
+	got, err = client.GetNextFilesFromPath(directory, 6, []string{".mkv"}, playlist.FileSortModeEpisodeAsc)
+	require.NoError(t, err)
+	require.EqualValues(t, expected[:6], got)
+
+	// The last file is deleted, so it resumes after its episode
+	require.NoError(t, os.Remove(expected[5]))
+
+	got, err = client.GetNextFilesFromPath(directory, 1, []string{".mkv"}, playlist.FileSortModeEpisodeAsc)
+	require.NoError(t, err)
+	require.EqualValues(t, expected[6:7], got)
+}
@@ -0,0 +1,199 @@
+package playlist
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// _interleaveRootGroup is the group of the files placed directly on the path instead of on a subdirectory.
+const _interleaveRootGroup = "."
+
+// interleaveGroup returns the top-level subdirectory of the path given where the file path given is placed.
+// If the file is placed directly on the path, the root group is returned.
+func interleaveGroup(path, filePath string) string {
+	rel, err := filepath.Rel(path, filePath)
+	if err != nil {
+		return _interleaveRootGroup
+	}
+
+	parts := strings.SplitN(rel, string(filepath.Separator), 2)
+	if len(parts) < 2 {
+		return _interleaveRootGroup
+	}
+
+	return parts[0]
+}
+
+// interleaveGroups splits the file list given by the top-level subdirectory of the path given keeping
+// the order of the files of every group. It returns the groups and their names sorted following the natural order.
+func interleaveGroups(path string, fileList []string) ([][]string, []string) {
+	files := make(map[string][]string)
+
+	var names []string
+
+	for _, file := range fileList {
+		group := interleaveGroup(path, file)
+		if _, found := files[group]; !found {
+			names = append(names, group)
+		}
+
+		files[group] = append(files[group], file)
+	}
+
+	sort.SliceStable(names, func(i, j int) bool {
+		return compareNatural(names[i], names[j]) < 0
+	})
+
+	groups := make([][]string, len(names))
+	for i, name := range names {
+		groups[i] = files[name]
+	}
+
+	return groups, names
+}
+
+// interleave returns the files of the groups given alternating between them: the first file of every group,
+// then the second file of every group and so on.
+func interleave(groups [][]string) []string {
+	var fileList []string
+
+	for i := 0; ; i++ {
+		found := false
+
+		for _, group := range groups {
+			if i < len(group) {
+				fileList = append(fileList, group[i])
+				found = true
+			}
+		}
+
+		if !found {
+			return fileList
+		}
+	}
+}
+
+// advanceInterleaved returns the next count files of the file listing given alternating between its top-level
+// subdirectories. Every subdirectory resumes after its own cursor and the first subdirectory used is the one
+// after the subdirectory of the last file of the state given. The subdirectories without more files are skipped.
+// When every subdirectory reached its end, it follows the end mode configured.
+func (p *Playlist) advanceInterleaved(path string, listing fileListing, count int, state *State) ([]string, error) {
+	groups, names := interleaveGroups(listing.path, listing.sortedFiles(state.ShuffleSeed))
+	hints := resumeHints(listing.sortOrder)
+
+	if state.Cursors == nil {
+		state.Cursors = make(map[string]Cursor)
+	}
+
+	positions := make([]int, len(groups))
+	first := 0
+
+	for i, group := range groups {
+		positions[i] = cursorPosition(group, state.Cursors[names[i]], state.ShuffleSeed, listing.sortOrder)
+
+		if state.Last != "" && names[i] == interleaveGroup(listing.path, state.Last) {
+			first = (i + 1) % len(groups)
+		}
+	}
+
+	var nextFiles []string
+
+	for len(nextFiles) < count {
+		found := false
+
+		for i := 0; i < len(groups) && len(nextFiles) < count; i++ {
+			group := (first + i) % len(groups)
+			if positions[group]+1 >= len(groups[group]) {
+				continue
+			}
+
+			positions[group]++
+			file := groups[group][positions[group]]
+			nextFiles = append(nextFiles, file)
+			state.Cursors[names[group]] = newCursor(file, hints)
+			found = true
+		}
+
+		if found {
+			continue
+		}
+
+		if p.OnEnd != EndModeLoop {
+			break
+		}
+
+		// Every subdirectory reached its end, so every one of them restarts from its first file
+		if listing.shuffled() {
+			state.rotateShuffleSeed()
+			groups, names = interleaveGroups(listing.path, listing.sortedFiles(state.ShuffleSeed))
+			positions = make([]int, len(groups))
+		}
+
+		for i := range positions {
+			positions[i] = -1
+		}
+
+		first = 0
+	}
+
+	if len(nextFiles) == 0 {
+		if p.OnEnd == EndModeError {
+			return nil, fmt.Errorf("%w: %s", ErrPlaylistEnded, path)
+		}
+
+		return nil, nil
+	}
+
+	state.setLast(nextFiles[len(nextFiles)-1], hints)
+
+	return nextFiles, nil
+}
+
+// cursorPosition returns the position of the cursor given on the files of a group sorted by the sort order
+// given following the permutation of the shuffle seed given. If there is no cursor, it returns -1.
+// If the cursor file doesn't exist anymore, it returns the position previous to where it would have been sorted.
+func cursorPosition(group []string, cursor Cursor, shuffleSeed uint64, sortOrder SortOrder) int {
+	return lastFilePosition(group, cursor.state(shuffleSeed), sortOrder)
+}
+
+// interleavedHistory returns the files of the interleaved file list given which were already returned,
+// that is, the files of every top-level subdirectory of the path given until its cursor.
+func interleavedHistory(path string, fileList []string, state State, sortOrder SortOrder) []string {
+	groups, names := interleaveGroups(path, fileList)
+	played := make(map[string]bool)
+
+	for i, group := range groups {
+		position := cursorPosition(group, state.Cursors[names[i]], state.ShuffleSeed, sortOrder)
+		for _, file := range group[:position+1] {
+			played[file] = true
+		}
+	}
+
+	var history []string
+
+	for _, file := range fileList {
+		if played[file] {
+			history = append(history, file)
+		}
+	}
+
+	return history
+}
+
+// setInterleavedPosition moves the cursors of the state given as if the interleaved file list given
+// was returned until the position given saving the resume hints given. If the position is -1, every cursor is removed.
+func setInterleavedPosition(path string, fileList []string, position int, hints ResumeHints, state *State) {
+	cursors := make(map[string]string)
+
+	for _, file := range fileList[:position+1] {
+		cursors[interleaveGroup(path, file)] = file
+	}
+
+	state.Cursors = make(map[string]Cursor, len(cursors))
+
+	for group, file := range cursors {
+		state.Cursors[group] = newCursor(file, hints)
+	}
+}
@@ -0,0 +1,269 @@
+package playlist_test
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/masch/goplaylist/internal/playlist"
+)
+
+func TestPlaylistInterleave(t *testing.T) {
+	directory, err := ioutil.TempDir("", "goplaylist")
+	require.NoError(t, err)
+
+	defer func() {
+		require.NoError(t, os.RemoveAll(directory))
+	}()
+
+	path := func(name string) string {
+		return filepath.Join(directory, name)
+	}
+
+	for _, dir := range []string{"show 10", "show 2", "show 2/extras", "podcast"} {
+		require.NoError(t, os.Mkdir(path(dir), 0o700))
+	}
+
+	for _, fileName := range []string{
+		"show 2/ep1.ext", "show 2/ep2.ext", "show 2/ep10.ext", "show 2/extras/ep11.ext",
+		"show 10/ep1.ext", "show 10/ep2.ext",
+		"podcast/ep1.ext",
+		"intro.ext",
+	} {
+		require.NoError(t, ioutil.WriteFile(path(fileName), nil, 0o600))
+	}
+
+	spec := playlist.SortSpec{{Mode: playlist.FileSortModeInterleave}, {Mode: playlist.FileSortModeNaturalAsc}}
+	extensions := []string{".ext"}
+	client := playlist.Playlist{Store: playlist.NewIniStateStore(path("state.ini"))}
+
+	got, err := client.ListFilesFromPath(directory, extensions, spec)
+	require.NoError(t, err)
+	require.EqualValues(t, []string{
+		path("intro.ext"), path("podcast/ep1.ext"), path("show 2/ep1.ext"), path("show 10/ep1.ext"),
+		path("show 2/ep2.ext"), path("show 10/ep2.ext"),
+		path("show 2/ep10.ext"),
+		path("show 2/extras/ep11.ext"),
+	}, got)
+
+	got, err = client.GetNextFilesFromPath(directory, 5, extensions, spec)
+	require.NoError(t, err)
+	require.EqualValues(t, []string{
+		path("intro.ext"), path("podcast/ep1.ext"), path("show 2/ep1.ext"), path("show 10/ep1.ext"),
+		path("show 2/ep2.ext"),
+	}, got)
+
+	// A new episode of a finished subdirectory is listed on its turn
+	require.NoError(t, ioutil.WriteFile(path("podcast/ep2.ext"), nil, 0o600))
+
+	got, err = client.PeekNextFilesFromPath(directory, 3, extensions, spec)
+	require.NoError(t, err)
+	require.EqualValues(t, []string{path("show 10/ep2.ext"), path("podcast/ep2.ext"), path("show 2/ep10.ext")}, got)
+
+	status, err := client.StatusFromPath(directory, extensions, spec)
+	require.NoError(t, err)
+	require.EqualValues(t, playlist.Status{
+		Last: path("show 2/ep2.ext"), Index: 5, Total: 9, Remaining: 4, Progress: float64(5) * 100 / 9,
+	}, status)
+
+	history, err := client.HistoryFromPath(directory, extensions, spec)
+	require.NoError(t, err)
+	require.EqualValues(t, []string{
+		path("intro.ext"), path("podcast/ep1.ext"), path("show 2/ep1.ext"), path("show 10/ep1.ext"),
+		path("show 2/ep2.ext"),
+	}, history)
+
+	// The reserved files move the cursors once they are acknowledged
+	got, err = client.ReserveNextFilesFromPath(directory, 2, extensions, spec)
+	require.NoError(t, err)
+	require.EqualValues(t, []string{path("show 10/ep2.ext"), path("podcast/ep2.ext")}, got)
+
+	_, err = client.AckFilesFromPath(directory, nil)
+	require.NoError(t, err)
+
+	got, err = client.GetNextFilesFromPath(directory, 3, extensions, spec)
+	require.NoError(t, err)
+	require.EqualValues(t, []string{path("show 2/ep10.ext"), path("show 2/extras/ep11.ext")}, got)
+
+	got, err = client.GetNextFilesFromPath(directory, 1, extensions, spec)
+	require.NoError(t, err)
+	require.Empty(t, got)
+
+	// Looping restarts every subdirectory
+	client.OnEnd = playlist.EndModeLoop
+
+	got, err = client.GetNextFilesFromPath(directory, 3, extensions, spec)
+	require.NoError(t, err)
+	require.EqualValues(t, []string{path("intro.ext"), path("podcast/ep1.ext"), path("show 2/ep1.ext")}, got)
+
+	// Seeking moves the cursors as if the files were listed until the file given
+	require.NoError(t, client.SeekFileFromPath(directory, path("show 10/ep2.ext"), extensions, spec))
+
+	got, err = client.GetNextFilesFromPath(directory, 2, extensions, spec)
+	require.NoError(t, err)
+	require.EqualValues(t, []string{path("show 10/ep2.ext"), path("show 2/ep10.ext")}, got)
+
+	require.NoError(t, client.RewindFromPath(directory, 1, extensions, spec))
+
+	got, err = client.GetNextFilesFromPath(directory, 1, extensions, spec)
+	require.NoError(t, err)
+	require.EqualValues(t, []string{path("show 2/ep10.ext")}, got)
+
+	client.OnEnd = playlist.EndModeError
+
+	require.NoError(t, client.SeekIndexFromPath(directory, 9, extensions, spec))
+
+	got, err = client.GetNextFilesFromPath(directory, 1, extensions, spec)
+	require.NoError(t, err)
+	require.EqualValues(t, []string{path("show 2/extras/ep11.ext")}, got)
+
+	_, err = client.GetNextFilesFromPath(directory, 1, extensions, spec)
+	require.True(t, errors.Is(err, playlist.ErrPlaylistEnded))
+}
+
+func TestPlaylistInterleaveResumeFromMissingCursor(t *testing.T) {
+	directory, clearFunc := createTemporaryDirectory(t)
+	defer clearFunc()
+
+	path := func(name string) string {
+		return filepath.Join(directory, name)
+	}
+
+	require.NoError(t, os.Mkdir(path("a"), 0o700))
+	require.NoError(t, os.Mkdir(path("b"), 0o700))
+
+	modTime := time.Date(2021, time.March, 4, 10, 20, 30, 0, time.UTC)
+	createFileWithTimes(t, path("a/z.ext"), modTime)
+	createFileWithTimes(t, path("a/y.ext"), modTime.Add(time.Hour))
+	createFileWithTimes(t, path("a/x.ext"), modTime.Add(2*time.Hour))
+	createFileWithTimes(t, path("b/1.ext"), modTime)
+	createFileWithTimes(t, path("b/2.ext"), modTime.Add(time.Hour))
+
+	spec := playlist.SortSpec{{Mode: playlist.FileSortModeInterleave}, {Mode: playlist.FileSortModeTimestampCreationAsc}}
+	extensions := []string{".ext"}
+	client := playlist.Playlist{Store: playlist.NewIniStateStore(path("state.ini"))}
+
+	got, err := client.GetNextFilesFromPath(directory, 3, extensions, spec)
+	require.NoError(t, err)
+	require.EqualValues(t, []string{path("a/z.ext"), path("b/1.ext"), path("a/y.ext")}, got)
+
+	// The cursor file is deleted, so its subdirectory resumes after its modification time
+	require.NoError(t, os.Remove(path("a/y.ext")))
+
+	got, err = client.GetNextFilesFromPath(directory, 2, extensions, spec)
+	require.NoError(t, err)
+	require.EqualValues(t, []string{path("b/2.ext"), path("a/x.ext")}, got)
+
+	// The cursor file of a shuffled subdirectory is deleted, so it resumes where it would have been shuffled
+	shuffled := path("shuffled")
+	require.NoError(t, os.Mkdir(shuffled, 0o700))
+
+	for i := 1; i <= 8; i++ {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(shuffled, fmt.Sprintf("ep%d.ext", i)), nil, 0o600))
+	}
+
+	store := playlist.NewMemoryStateStore()
+	require.NoError(t, store.Save(shuffled, playlist.State{ShuffleSeed: 1, NextShuffleSeed: 2}))
+
+	client = playlist.Playlist{Store: store}
+	spec = playlist.SortSpec{{Mode: playlist.FileSortModeInterleave}, {Mode: playlist.FileSortModeShuffle}}
+
+	permutation, err := client.PeekNextFilesFromPath(shuffled, 8, extensions, spec)
+	require.NoError(t, err)
+
+	got, err = client.GetNextFilesFromPath(shuffled, 3, extensions, spec)
+	require.NoError(t, err)
+	require.EqualValues(t, permutation[:3], got)
+
+	require.NoError(t, os.Remove(permutation[2]))
+
+	got, err = client.GetNextFilesFromPath(shuffled, 1, extensions, spec)
+	require.NoError(t, err)
+	require.EqualValues(t, permutation[3:4], got)
+}
+
+func TestPlaylistInterleaveResumeFromMissingCursorByResumeHints(t *testing.T) {
+	tt := []struct {
+		name     string
+		sortMode playlist.FileSortMode
+		files    map[string]string
+		listed   []string
+		next     []string
+	}{
+		{
+			name:     "size",
+			sortMode: playlist.FileSortModeSizeAsc,
+			files: map[string]string{
+				"a/notes.mp3": "duration/notes.mp3", "a/xing.mp3": "duration/xing.mp3", "a/cbr.mp3": "duration/cbr.mp3",
+				"b/notes.mp3": "duration/notes.mp3", "b/short.wav": "duration/short.wav",
+			},
+			listed: []string{"a/notes.mp3", "b/notes.mp3", "a/xing.mp3"},
+			next:   []string{"b/short.wav", "a/cbr.mp3"},
+		},
+		{
+			name:     "tags",
+			sortMode: playlist.FileSortModeTagsAsc,
+			files: map[string]string{
+				"a/id3v22.mp3": "tags/id3v22.mp3", "a/id3v24.mp3": "tags/id3v24.mp3", "a/id3v23.mp3": "tags/id3v23.mp3",
+				"b/vorbis.ogg": "tags/vorbis.ogg", "b/opus.opus": "tags/opus.opus",
+			},
+			listed: []string{"a/id3v22.mp3", "b/vorbis.ogg", "a/id3v24.mp3"},
+			next:   []string{"b/opus.opus", "a/id3v23.mp3"},
+		},
+		{
+			name:     "duration",
+			sortMode: playlist.FileSortModeDurationAsc,
+			files: map[string]string{
+				"a/short.wav": "duration/short.wav", "a/cbr.mp3": "duration/cbr.mp3", "a/xing.mp3": "duration/xing.mp3",
+				"b/cbr.mp3": "duration/cbr.mp3", "b/xing.mp3": "duration/xing.mp3",
+			},
+			listed: []string{"a/short.wav", "b/cbr.mp3", "a/cbr.mp3"},
+			next:   []string{"b/xing.mp3", "a/xing.mp3"},
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			directory, clearFunc := createTemporaryDirectory(t)
+			defer clearFunc()
+
+			path := func(names ...string) []string {
+				paths := make([]string, len(names))
+				for i, name := range names {
+					paths[i] = filepath.Join(directory, name)
+				}
+
+				return paths
+			}
+
+			require.NoError(t, os.Mkdir(filepath.Join(directory, "a"), 0o700))
+			require.NoError(t, os.Mkdir(filepath.Join(directory, "b"), 0o700))
+
+			for target, source := range tc.files {
+				copyTestdataFile(t, filepath.Join("testdata", source), filepath.Join(directory, target))
+			}
+
+			spec := playlist.SortSpec{{Mode: playlist.FileSortModeInterleave}, {Mode: tc.sortMode}}
+			extensions := []string{".mp3", ".wav", ".ogg", ".opus"}
+			client := playlist.Playlist{Store: playlist.NewIniStateStore(filepath.Join(directory, "state.ini"))}
+
+			got, err := client.GetNextFilesFromPath(directory, len(tc.listed), extensions, spec)
+			require.NoError(t, err)
+			require.EqualValues(t, path(tc.listed...), got)
+
+			// The cursor file is deleted, so its subdirectory resumes after the value saved on its cursor
+			require.NoError(t, os.Remove(filepath.Join(directory, tc.listed[len(tc.listed)-1])))
+
+			got, err = client.GetNextFilesFromPath(directory, len(tc.next), extensions, spec)
+			require.NoError(t, err)
+			require.EqualValues(t, path(tc.next...), got)
+		})
+	}
+}
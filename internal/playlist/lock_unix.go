@@ -0,0 +1,40 @@
+//go:build !windows
+// +build !windows
+
+package playlist
+
+import (
+	"os"
+	"syscall"
+)
+
+// lockFile acquires an exclusive advisory lock (flock) on the file name given, creating it if needed.
+// It blocks until the lock is available and returns the function to release it.
+func lockFile(fileName string) (func() error, error) {
+	file, err := os.OpenFile(fileName, os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		err = syscall.Flock(int(file.Fd()), syscall.LOCK_EX)
+		if err != syscall.EINTR {
+			break
+		}
+	}
+
+	if err != nil {
+		_ = file.Close()
+		return nil, &os.PathError{Op: "flock", Path: fileName, Err: err}
+	}
+
+	return func() error {
+		// Closing the file releases the lock even if the unlock fails
+		unlockErr := syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+		if err := file.Close(); err != nil {
+			return err
+		}
+
+		return unlockErr
+	}, nil
+}
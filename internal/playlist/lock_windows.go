@@ -0,0 +1,47 @@
+package playlist
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+const _lockFileExclusiveLock = 0x00000002
+
+var (
+	_kernel32         = syscall.NewLazyDLL("kernel32.dll")
+	_procLockFileEx   = _kernel32.NewProc("LockFileEx")
+	_procUnlockFileEx = _kernel32.NewProc("UnlockFileEx")
+)
+
+// lockFile acquires an exclusive lock (LockFileEx) on the file name given, creating it if needed.
+// It blocks until the lock is available and returns the function to release it.
+func lockFile(fileName string) (func() error, error) {
+	file, err := os.OpenFile(fileName, os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return nil, err
+	}
+
+	overlapped := new(syscall.Overlapped)
+
+	result, _, err := _procLockFileEx.Call(
+		file.Fd(), _lockFileExclusiveLock, 0, 1, 0, uintptr(unsafe.Pointer(overlapped)))
+	if result == 0 {
+		_ = file.Close()
+		return nil, &os.PathError{Op: "LockFileEx", Path: fileName, Err: err}
+	}
+
+	return func() error {
+		// Closing the file releases the lock even if the unlock fails
+		result, _, unlockErr := _procUnlockFileEx.Call(file.Fd(), 0, 1, 0, uintptr(unsafe.Pointer(overlapped)))
+		if err := file.Close(); err != nil {
+			return err
+		}
+
+		if result == 0 {
+			return unlockErr
+		}
+
+		return nil
+	}, nil
+}
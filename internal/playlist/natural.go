@@ -0,0 +1,95 @@
+package playlist
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// compareNatural compares the strings given following the natural (human) order and returns
+// a negative number when a is sorted before b, a positive number when a is sorted after b and zero when they are equal.
+// The digit runs are compared by their numeric value and the rest of characters are compared case-insensitively,
+// so "Ep2" is sorted before "ep10". The strings equal under these rules are compared byte-wise as the last resort.
+func compareNatural(a, b string) int {
+	i, j := 0, 0
+
+	for i < len(a) && j < len(b) {
+		if isDigit(a[i]) && isDigit(b[j]) {
+			digitsA, digitsB := digitRun(a[i:]), digitRun(b[j:])
+
+			if result := compareNumbers(digitsA, digitsB); result != 0 {
+				return result
+			}
+
+			i, j = i+len(digitsA), j+len(digitsB)
+
+			continue
+		}
+
+		runeA, sizeA := utf8.DecodeRuneInString(a[i:])
+		runeB, sizeB := utf8.DecodeRuneInString(b[j:])
+
+		if lowerA, lowerB := unicode.ToLower(runeA), unicode.ToLower(runeB); lowerA != lowerB {
+			if lowerA < lowerB {
+				return -1
+			}
+
+			return 1
+		}
+
+		i, j = i+sizeA, j+sizeB
+	}
+
+	switch {
+	case len(a)-i < len(b)-j:
+		return -1
+	case len(a)-i > len(b)-j:
+		return 1
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// compareNumbers compares the numeric value of the digit runs given of any length.
+func compareNumbers(a, b string) int {
+	a, b = trimLeadingZeros(a), trimLeadingZeros(b)
+
+	switch {
+	case len(a) != len(b):
+		return len(a) - len(b)
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// digitRun returns the leading ASCII digits of the string given.
+func digitRun(s string) string {
+	end := 0
+	for end < len(s) && isDigit(s[end]) {
+		end++
+	}
+
+	return s[:end]
+}
+
+// trimLeadingZeros returns the digit run given without leading zeros.
+func trimLeadingZeros(digits string) string {
+	start := 0
+	for start < len(digits) && digits[start] == '0' {
+		start++
+	}
+
+	return digits[start:]
+}
+
+// isDigit returns whether the byte given is an ASCII digit.
+func isDigit(b byte) bool {
+	return '0' <= b && b <= '9'
+}
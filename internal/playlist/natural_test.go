@@ -0,0 +1,99 @@
+package playlist_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/masch/goplaylist/internal/playlist"
+)
+
+func TestListFilesByNaturalFileName(t *testing.T) {
+	tt := []struct {
+		name     string
+		expected []string
+	}{
+		{
+			name:     "OK_numbers",
+			expected: []string{"ep1.mkv", "ep2.mkv", "ep9.mkv", "ep10.mkv", "ep11.mkv", "ep100.mkv"},
+		},
+		{
+			name:     "OK_case_insensitive",
+			expected: []string{"a.mkv", "B.mkv", "c.mkv", "D.mkv"},
+		},
+		{
+			name:     "OK_leading_zeros",
+			expected: []string{"lecture 0.mkv", "lecture 00.mkv", "lecture 1.mkv", "lecture 02.mkv", "lecture 3.mkv"},
+		},
+		{
+			name:     "OK_several_digit_runs",
+			expected: []string{"s1e2.mkv", "s1e10.mkv", "s2e1.mkv", "s10e1.mkv"},
+		},
+		{
+			name:     "OK_numbers_greater_than_uint64",
+			expected: []string{"99999999999999999999.mkv", "100000000000000000000.mkv"},
+		},
+		{
+			name:     "OK_equal_ignoring_case",
+			expected: []string{"FILE.mkv", "File.mkv", "file.mkv"},
+		},
+		{
+			name:     "OK_unicode",
+			expected: []string{"café 2.mkv", "Café 10.mkv", "été 1.mkv", "ÉTÉ.mkv"},
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			directory, err := ioutil.TempDir("", "goplaylist")
+			require.NoError(t, err)
+
+			defer func() {
+				require.NoError(t, os.RemoveAll(directory))
+			}()
+
+			var expected []string
+
+			// The files are created on reverse order, so the creation doesn't define the order
+			for i := len(tc.expected) - 1; i >= 0; i-- {
+				require.NoError(t, ioutil.WriteFile(filepath.Join(directory, tc.expected[i]), nil, 0o600))
+				expected = append([]string{filepath.Join(directory, tc.expected[i])}, expected...)
+			}
+
+			got, err := playlist.ListFilesByNaturalFileName(directory, []string{".mkv"})
+			require.NoError(t, err)
+			require.EqualValues(t, expected, got)
+		})
+	}
+}
+
+func TestPlaylistNaturalSortResumeFromMissingLastFile(t *testing.T) {
+	directory, err := ioutil.TempDir("", "goplaylist")
+	require.NoError(t, err)
+
+	defer func() {
+		require.NoError(t, os.RemoveAll(directory))
+	}()
+
+	for _, fileName := range []string{"ep1.mkv", "ep2.mkv", "ep10.mkv", "ep11.mkv"} {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(directory, fileName), nil, 0o600))
+	}
+
+	store := playlist.NewMemoryStateStore()
+	client := playlist.Playlist{Store: store}
+
+	got, err := client.GetNextFilesFromPath(directory, 2, []string{".mkv"}, playlist.FileSortModeNaturalAsc)
+	require.NoError(t, err)
+	require.EqualValues(t, []string{filepath.Join(directory, "ep1.mkv"), filepath.Join(directory, "ep2.mkv")}, got)
+
+	// The last file is deleted, so it resumes after it following the natural order
+	require.NoError(t, os.Remove(filepath.Join(directory, "ep2.mkv")))
+
+	got, err = client.GetNextFilesFromPath(directory, 1, []string{".mkv"}, playlist.FileSortModeNaturalAsc)
+	require.NoError(t, err)
+	require.EqualValues(t, []string{filepath.Join(directory, "ep10.mkv")}, got)
+}
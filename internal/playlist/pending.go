@@ -0,0 +1,158 @@
+package playlist
+
+import (
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrFileNotPending represent the error when a file acknowledged is not pending.
+	ErrFileNotPending = fmt.Errorf("file not pending")
+)
+
+// ReserveNextFilesFromPath returns the next files names on the path given as GetNextFilesFromPath does,
+// but instead of saving the last file name returned, it saves them as pending until they are acknowledged
+// by AckFilesFromPath. While there are pending files, they are returned again on every call.
+// If the pending TTL configured is reached, the reservation is cancelled and the next files are reserved again
+// from the last file name acknowledged, so the files not played are never skipped.
+func (p *Playlist) ReserveNextFilesFromPath(
+	path string, count int, fileExtension []string, sortMode SortOrder) ([]string, error) {
+	listing, err := newFileListing(path, fileExtension, sortMode)
+	if err != nil {
+		return nil, err
+	}
+
+	// If there is not files, return empty list
+	if len(listing.files) == 0 {
+		return nil, nil
+	}
+
+	var nextFiles []string
+
+	if err := p.stateStore().Update(path, func(state *State) error {
+		if p.PendingTTL > 0 && len(state.Pending) > 0 && time.Since(state.PendingSince) >= p.PendingTTL {
+			p.logf("pending files of path %q expired, they are reserved again", path)
+
+			// The last file name and the cursors are kept, so the same files are reserved again
+			state.Pending = nil
+			state.PendingSince = time.Time{}
+		}
+
+		if listing.shuffled() {
+			state.initShuffleSeeds(path)
+		}
+
+		fileList := listing.sorted(state.ShuffleSeed)
+
+		// Offer again the pending files which still exist
+		for _, file := range state.Pending {
+			if containsFile(fileList, file) {
+				nextFiles = append(nextFiles, file)
+			}
+		}
+
+		if len(nextFiles) > 0 {
+			return nil
+		}
+
+		// Advance a copy of the state in order to keep the last file name until the acknowledgement
+		committed := state.clone()
+
+		nextFiles, err = p.advance(path, listing, count, &committed)
+		if err != nil {
+			return err
+		}
+
+		// The pending files follow the permutation of the shuffle seed reached
+		state.ShuffleSeed, state.NextShuffleSeed = committed.ShuffleSeed, committed.NextShuffleSeed
+
+		// The acknowledgement saves the resume hints of the sort order which reserved the files
+		state.ResumeHints = committed.ResumeHints
+
+		// The cursors are enabled, so the acknowledgement moves them
+		if committed.Cursors != nil && state.Cursors == nil {
+			state.Cursors = make(map[string]Cursor)
+		}
+		state.Pending = nextFiles
+		state.PendingSince = time.Now()
+
+		if len(nextFiles) == 0 {
+			state.PendingSince = time.Time{}
+		}
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return nextFiles, nil
+}
+
+// AckFilesFromPath acknowledges the pending files names of the path given moving the last file name to them.
+// Acknowledging a file also acknowledges every file pending before it. If there are no files given,
+// every pending file is acknowledged. It returns the file names acknowledged.
+func (p *Playlist) AckFilesFromPath(path string, files []string) ([]string, error) {
+	var acknowledged []string
+
+	if err := p.stateStore().Update(path, func(state *State) error {
+		count := len(state.Pending)
+
+		if len(files) > 0 {
+			count = 0
+
+			for _, file := range files {
+				position := indexOfFile(state.Pending, file)
+				if position < 0 {
+					return fmt.Errorf("%w: %s", ErrFileNotPending, file)
+				}
+
+				if position+1 > count {
+					count = position + 1
+				}
+			}
+		}
+
+		acknowledged = acknowledge(path, state, count)
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return acknowledged, nil
+}
+
+// acknowledge moves the last file name of the state given of the path given to the pending file of the position
+// count given, moving the cursors of the pending files acknowledged too. It returns the file names acknowledged.
+func acknowledge(path string, state *State, count int) []string {
+	if count == 0 {
+		return nil
+	}
+
+	acknowledged := state.Pending[:count]
+
+	for _, file := range acknowledged {
+		state.setCursor(path, file)
+	}
+
+	state.setLast(acknowledged[len(acknowledged)-1], state.ResumeHints)
+	state.Pending = state.Pending[count:]
+
+	if len(state.Pending) == 0 {
+		state.Pending = nil
+		state.PendingSince = time.Time{}
+	}
+
+	return acknowledged
+}
+
+// indexOfFile returns the position of the file path given on the file list given or -1 if it is not found.
+func indexOfFile(fileList []string, filePath string) int {
+	for i, file := range fileList {
+		if file == filePath {
+			return i
+		}
+	}
+
+	return -1
+}
@@ -0,0 +1,106 @@
+package playlist_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/masch/goplaylist/internal/playlist"
+)
+
+func TestPlaylistReserveAndAck(t *testing.T) {
+	store := playlist.NewMemoryStateStore()
+	client := playlist.Playlist{Store: store}
+	extensions := []string{".ext"}
+
+	first := []string{
+		"testdata/example_1/dir_1/file_1_1.ext",
+		"testdata/example_1/dir_1/file_1_2.ext",
+		"testdata/example_1/dir_1/file_1_3.ext",
+	}
+
+	// The reserved files are offered again until they are acknowledged
+	for i := 0; i < 2; i++ {
+		got, err := client.ReserveNextFilesFromPath("testdata/example_1", 3, extensions, playlist.FileSortModeFileNameAsc)
+		require.NoError(t, err)
+		require.EqualValues(t, first, got)
+	}
+
+	state, err := store.Load("testdata/example_1")
+	require.NoError(t, err)
+	require.Empty(t, state.Last)
+	require.EqualValues(t, first, state.Pending)
+	require.False(t, state.PendingSince.IsZero())
+
+	// Acknowledging a file acknowledges every file reserved before it
+	got, err := client.AckFilesFromPath("testdata/example_1", []string{"testdata/example_1/dir_1/file_1_2.ext"})
+	require.NoError(t, err)
+	require.EqualValues(t, first[:2], got)
+
+	got, err = client.ReserveNextFilesFromPath("testdata/example_1", 3, extensions, playlist.FileSortModeFileNameAsc)
+	require.NoError(t, err)
+	require.EqualValues(t, first[2:], got)
+
+	_, err = client.AckFilesFromPath("testdata/example_1", []string{"testdata/example_1/dir_1/file_1_1.ext"})
+	require.True(t, errors.Is(err, playlist.ErrFileNotPending))
+
+	// Acknowledging without files acknowledges every file reserved
+	got, err = client.AckFilesFromPath("testdata/example_1", nil)
+	require.NoError(t, err)
+	require.EqualValues(t, first[2:], got)
+
+	state, err = store.Load("testdata/example_1")
+	require.NoError(t, err)
+	require.EqualValues(t, "testdata/example_1/dir_1/file_1_3.ext", state.Last)
+	require.Empty(t, state.Pending)
+	require.True(t, state.PendingSince.IsZero())
+
+	got, err = client.ReserveNextFilesFromPath("testdata/example_1", 2, extensions, playlist.FileSortModeFileNameAsc)
+	require.NoError(t, err)
+	require.EqualValues(t, []string{
+		"testdata/example_1/dir_2/file_2_1.ext",
+		"testdata/example_1/dir_2/file_2_2.ext",
+	}, got)
+
+	// Getting the next files directly discards the reserved files
+	got, err = client.GetNextFilesFromPath("testdata/example_1", 1, extensions, playlist.FileSortModeFileNameAsc)
+	require.NoError(t, err)
+	require.EqualValues(t, []string{"testdata/example_1/dir_2/file_2_1.ext"}, got)
+
+	got, err = client.AckFilesFromPath("testdata/example_1", nil)
+	require.NoError(t, err)
+	require.Empty(t, got)
+}
+
+func TestPlaylistReserveExpired(t *testing.T) {
+	store := playlist.NewMemoryStateStore()
+	client := playlist.Playlist{Store: store, PendingTTL: time.Hour}
+	extensions := []string{".ext"}
+
+	require.NoError(t, store.Save("testdata/example_1", playlist.State{
+		Pending:      []string{"testdata/example_1/dir_1/file_1_1.ext"},
+		PendingSince: time.Now().Add(-2 * time.Hour),
+	}))
+
+	// The reservation expired, so the same files are reserved again instead of skipping them
+	got, err := client.ReserveNextFilesFromPath("testdata/example_1", 1, extensions, playlist.FileSortModeFileNameAsc)
+	require.NoError(t, err)
+	require.EqualValues(t, []string{"testdata/example_1/dir_1/file_1_1.ext"}, got)
+
+	state, err := store.Load("testdata/example_1")
+	require.NoError(t, err)
+	require.Empty(t, state.Last)
+	require.EqualValues(t, []string{"testdata/example_1/dir_1/file_1_1.ext"}, state.Pending)
+	require.True(t, time.Since(state.PendingSince) < time.Hour)
+
+	// The files reserved again are acknowledged as usual
+	got, err = client.AckFilesFromPath("testdata/example_1", nil)
+	require.NoError(t, err)
+	require.EqualValues(t, []string{"testdata/example_1/dir_1/file_1_1.ext"}, got)
+
+	got, err = client.ReserveNextFilesFromPath("testdata/example_1", 1, extensions, playlist.FileSortModeFileNameAsc)
+	require.NoError(t, err)
+	require.EqualValues(t, []string{"testdata/example_1/dir_1/file_1_2.ext"}, got)
+}
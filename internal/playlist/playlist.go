@@ -3,12 +3,9 @@ package playlist
 
 import (
 	"fmt"
+	"log"
 	"os"
-	"path/filepath"
-	"sort"
-	"strings"
-
-	"gopkg.in/ini.v1"
+	"time"
 )
 
 const (
@@ -21,164 +18,409 @@ type FileSortMode uint
 
 const (
 	// FileSortModeFileNameAsc represents the file sort mode by file name ascendant.
-	FileSortModeFileNameAsc = iota
+	FileSortModeFileNameAsc FileSortMode = iota
 
 	// FileSortModeTimestampCreationAsc represents the file sort mode by file timestamp creation ascendant.
+	// The timestamp used is the file modification time, so it is the same sort mode as FileSortModeModTimeAsc.
 	FileSortModeTimestampCreationAsc
+
+	// FileSortModeNaturalAsc represents the file sort mode by file name ascendant following the natural order,
+	// that is, comparing the digit runs by their numeric value and case-insensitively.
+	FileSortModeNaturalAsc
+
+	// FileSortModeBirthTimeAsc represents the file sort mode by file birth time ascendant.
+	// If the birth time can't be read, the modification time is used.
+	FileSortModeBirthTimeAsc
+
+	// FileSortModeDirectoryAsc represents the file sort mode by file directory ascendant following the natural order.
+	// It is meant to be the first key of a sort specification, so the files of every directory are kept together.
+	FileSortModeDirectoryAsc
+
+	// FileSortModeShuffle represents the file sort mode by a random permutation. The permutation is defined by
+	// the random shuffle seed saved on the state, so it is kept between executions, and it changes to a new random
+	// one only when the end of the file list is reached and the end mode restarts from the first file,
+	// so no file is repeated until every file was returned.
+	FileSortModeShuffle
+
+	// FileSortModeEpisodeAsc represents the file sort mode by episode ascendant. The season and episode numbers
+	// are parsed from the file names by ParseEpisode and the files of every directory are sorted together.
+	// The files without episode are sorted after the rest following the natural order.
+	FileSortModeEpisodeAsc
+
+	// FileSortModeRegexp represents the file sort mode by the values captured on the file names by a regular
+	// expression. The regular expression is given by the SortKeyRegexp of the sort key.
+	FileSortModeRegexp
+
+	// FileSortModeTagsAsc represents the file sort mode by the tags read by ReadTags ascendant, that is,
+	// by album artist, album, disc number and track number. The files without tags are sorted after the rest
+	// by file name.
+	FileSortModeTagsAsc
+
+	// FileSortModeInterleave represents the file sort mode which alternates between the top-level subdirectories
+	// of the path: the first file of every subdirectory, then the second one and so on. The files of every
+	// subdirectory are sorted by the rest of keys of the sort specification and every subdirectory resumes
+	// after its own last file, which is saved on the state cursors.
+	FileSortModeInterleave
+
+	// FileSortModeSizeAsc represents the file sort mode by file size ascendant.
+	FileSortModeSizeAsc
+
+	// FileSortModeDurationAsc represents the file sort mode by the media duration read by ReadDuration ascendant.
+	// The files without duration are sorted after the rest by file name.
+	FileSortModeDurationAsc
+)
+
+// FileSortModeModTimeAsc represents the file sort mode by file modification time ascendant.
+const FileSortModeModTimeAsc = FileSortModeTimestampCreationAsc
+
+// An EndMode represents the behavior when there are no more files to list after the last file used.
+type EndMode uint
+
+const (
+	// EndModeStop represents the end mode which returns an empty file list.
+	EndModeStop EndMode = iota
+
+	// EndModeLoop represents the end mode which restarts from the first file,
+	// filling the remainder of the count with the first files.
+	EndModeLoop
+
+	// EndModeError represents the end mode which returns the ErrPlaylistEnded error.
+	EndModeError
 )
 
 var (
 	// ErrUnsupportedFileSortMode represent the error when a file sort mode given is unknown.
 	ErrUnsupportedFileSortMode = fmt.Errorf("unsupported file sort mode")
+
+	// ErrPlaylistEnded represent the error when there are no more files to list and the end mode is EndModeError.
+	ErrPlaylistEnded = fmt.Errorf("playlist ended")
 )
 
 // Playlist contains the mechanism to list file names.
 type Playlist struct {
+	// Store keeps the last file name processed of every path.
+	// If it is nil, the state is saved on the cfg.ini file of the current working directory.
+	Store StateStore
+
+	// OnEnd is the behavior when there are no more files to list. By default, it is EndModeStop.
+	OnEnd EndMode
+
+	// PendingTTL is the duration after which the reservation of the files reserved by ReserveNextFilesFromPath
+	// is cancelled, so they are reserved again from the last file acknowledged. If it is zero,
+	// the reserved files never expire.
+	PendingTTL time.Duration
+
+	// Logger reports recoverable situations such as a last file name processed that doesn't exist anymore.
+	// If it is nil, nothing is reported.
+	Logger *log.Logger
+}
+
+// logf reports the message given on the logger configured.
+func (p *Playlist) logf(format string, v ...interface{}) {
+	if p.Logger != nil {
+		p.Logger.Printf(format, v...)
+	}
+}
+
+// stateStore returns the state store configured or the default ini file state store.
+func (p *Playlist) stateStore() StateStore {
+	if p.Store == nil {
+		return NewIniStateStore(_iniFileName)
+	}
+
+	return p.Store
 }
 
 // GetNextFilesFromPath returns existing files names on the path given following the next steps:
 // 1. List file names by the sort mode given and filter them by the extension given.
-// 2. Load from the ini configuration file which was the last file name processed.
+// 2. Load from the state store which was the last file name processed.
 // If there is no file, it will return empty string.
+// If the file was deleted or renamed, it resumes from the position where it would have been sorted.
 // 3. Get next N count value given file from the last file name processed.
-// 4. Save the last file name returned on the filter list.
+// If the end of the file list is reached, it follows the end mode configured.
+// 4. Save the last file name returned on the filter list on the state store.
 // 5. Return the full list to processed.
-func (*Playlist) GetNextFilesFromPath(
-	path string, count int, fileExtension []string, sortMode FileSortMode) ([]string, error) {
-	var (
-		fileList []string
-		err      error
-	)
-
-	// Sort files by the sort mode given
-	switch sortMode {
-	case FileSortModeFileNameAsc:
-		// List files from the path given order by file name ascendant
-		fileList, err = ListFilesByFileNamePath(path, fileExtension)
-	case FileSortModeTimestampCreationAsc:
-		// List files from the path given order by timestamp creation ascendant
-		fileList, err = ListFilesByDateCreation(path, fileExtension)
-	default:
-		return nil, fmt.Errorf("%w: %d", ErrUnsupportedFileSortMode, sortMode)
-	}
-
+// Steps 2 to 4 are run holding the state store lock.
+func (p *Playlist) GetNextFilesFromPath(
+	path string, count int, fileExtension []string, sortMode SortOrder) ([]string, error) {
+	listing, err := newFileListing(path, fileExtension, sortMode)
 	if err != nil {
 		return nil, err
 	}
 
 	// If there is not files, return empty list
-	if len(fileList) == 0 {
+	if len(listing.files) == 0 {
 		return nil, nil
 	}
 
-	// Load ini file
-	cfg, err := ini.LooseLoad(_iniFileName)
+	var nextFiles []string
+
+	// Load the last file name processed, get the next files and save the new last file name
+	// holding the state store lock, so concurrent calls never return the same files
+	if err := p.stateStore().Update(path, func(state *State) error {
+		nextFiles, err = p.advance(path, listing, count, state)
+		if err != nil {
+			return err
+		}
+
+		// The files returned are considered acknowledged, so the reserved files are discarded
+		if len(nextFiles) > 0 {
+			state.Pending = nil
+			state.PendingSince = time.Time{}
+		}
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return nextFiles, nil
+}
+
+// ListFilesByFileNamePath lists file path sorted by file name ascendant on the given path
+// and filter them with extension given.
+func ListFilesByFileNamePath(path string, filterExtensions []string) ([]string, error) {
+	return listFiles(path, filterExtensions, FileSortModeFileNameAsc)
+}
+
+// ListFilesByDateCreation lists file path sorted by timestamp creation ascendant on the given path
+// and filter them with extension given. The timestamp used is the modification time
+// and the files with the same timestamp are sorted by file name.
+func ListFilesByDateCreation(path string, filterExtensions []string) ([]string, error) {
+	return listFiles(path, filterExtensions, FileSortModeTimestampCreationAsc)
+}
+
+// ListFilesByBirthTime lists file path sorted by birth time ascendant on the given path
+// and filter them with extension given. If the birth time of a file can't be read, its modification time is used.
+// The files with the same timestamp are sorted by file name.
+func ListFilesByBirthTime(path string, filterExtensions []string) ([]string, error) {
+	return listFiles(path, filterExtensions, FileSortModeBirthTimeAsc)
+}
+
+// ListFilesByNaturalFileName lists file path sorted by file name ascendant following the natural order
+// on the given path and filter them with extension given. The digit runs are compared by their numeric value
+// and the rest of characters case-insensitively, so "ep2" is sorted before "ep10".
+func ListFilesByNaturalFileName(path string, filterExtensions []string) ([]string, error) {
+	return listFiles(path, filterExtensions, FileSortModeNaturalAsc)
+}
+
+// PeekNextFilesFromPath returns the same files names GetNextFilesFromPath would return
+// on the path given but without saving the last file name returned, so the next call resumes
+// from the same position.
+func (p *Playlist) PeekNextFilesFromPath(
+	path string, count int, fileExtension []string, sortMode SortOrder) ([]string, error) {
+	listing, err := newFileListing(path, fileExtension, sortMode)
 	if err != nil {
 		return nil, err
 	}
-	// Tries to load the last file name processed
-	lastFileNameUsed := cfg.Section(path).Key(_iniLastFileNameProcessedSection).String()
 
-	// If the last file name used if the same of the last file list, it means that there is no more file to list
-	if lastFileNameUsed == fileList[len(fileList)-1] {
+	// If there is not files, return empty list
+	if len(listing.files) == 0 {
 		return nil, nil
 	}
 
-	// Get n count file names after the last file name used
-	nextFiles := GetNextFiles(fileList, count, lastFileNameUsed)
-	if len(nextFiles) == 0 {
-		return nil, nil
+	state, err := p.loadState(path, listing)
+	if err != nil {
+		return nil, err
 	}
 
-	// Save the last file used on the ini configuration
-	lastFile := nextFiles[len(nextFiles)-1]
-	cfg.Section(path).Key(_iniLastFileNameProcessedSection).SetValue(lastFile)
+	// The state modified is discarded
+	return p.advance(path, listing, count, &state)
+}
+
+// ListFilesFromPath returns every file name on the path given sorted by the sort mode given
+// and filtered by the extensions given. The state is only used to read the shuffle seed.
+func (p *Playlist) ListFilesFromPath(path string, fileExtension []string, sortMode SortOrder) ([]string, error) {
+	fileList, _, err := p.listFilesWithState(path, fileExtension, sortMode)
+	return fileList, err
+}
 
-	if err := cfg.SaveTo(_iniFileName); err != nil {
+// listFiles lists the file names on the path given sorted by the sort order given
+// and filtered by the extensions given. The files are shuffled by the initial shuffle seed.
+func listFiles(path string, fileExtension []string, sortOrder SortOrder) ([]string, error) {
+	listing, err := newFileListing(path, fileExtension, sortOrder)
+	if err != nil {
 		return nil, err
 	}
 
-	return nextFiles, nil
+	return listing.sorted(0), nil
 }
 
-// ListFilesByFileNamePath lists file path sorted by file name ascendant on the given path
-// and filter them with extension given.
-func ListFilesByFileNamePath(path string, filterExtensions []string) ([]string, error) {
-	var paths []string
+// listFilesWithState lists the file names on the path given sorted by the sort order given and filtered
+// by the extensions given, shuffling them by the shuffle seed of the state of the path. It returns the state too.
+func (p *Playlist) listFilesWithState(
+	path string, fileExtension []string, sortOrder SortOrder) ([]string, State, error) {
+	listing, err := newFileListing(path, fileExtension, sortOrder)
+	if err != nil {
+		return nil, State{}, err
+	}
 
-	// Walks on the path given finding all the find names and filter them by the extension given
-	if err := filepath.Walk(path, func(path string, f os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
+	state, err := p.loadState(path, listing)
+	if err != nil {
+		return nil, State{}, err
+	}
 
-		// Ignore if it is a directory
-		if f.IsDir() {
-			return nil
+	return listing.sorted(state.ShuffleSeed), state, nil
+}
+
+// loadState returns the state of the path given without modifying it. If the listing given is shuffled
+// and the shuffle seeds were not chosen yet, the seeds derived from the path are set on the state returned only,
+// so the files are listed following the same permutation the next files will follow.
+func (p *Playlist) loadState(path string, listing fileListing) (State, error) {
+	state, err := p.stateStore().Load(path)
+	if err != nil {
+		return State{}, err
+	}
+
+	if listing.shuffled() {
+		state.initShuffleSeeds(path)
+	}
+
+	return state, nil
+}
+
+// advance returns the next count files of the file listing given after the last file of the state given
+// and moves the state to the last file returned. When the end of the file list is reached,
+// it follows the end mode configured. If the listing is shuffled, the shuffle seeds are chosen when they were not
+// chosen yet and restarting from the first file moves the state to the next shuffle seed.
+// If the listing is interleaved, it follows its cursors.
+func (p *Playlist) advance(path string, listing fileListing, count int, state *State) ([]string, error) {
+	if listing.shuffled() {
+		state.initShuffleSeeds(path)
+	}
+
+	if listing.interleaved() {
+		return p.advanceInterleaved(path, listing, count, state)
+	}
+
+	fileList := listing.sorted(state.ShuffleSeed)
+	lastFileNameUsed := state.Last
+
+	// If the last file name used is not on the file list anymore, resume from where it would have been sorted
+	if lastFileNameUsed != "" && !containsFile(fileList, lastFileNameUsed) {
+		position := findMissingFilePosition(fileList, *state, listing.sortOrder)
+
+		switch {
+		case position == len(fileList):
+			p.logf("last file %q of path %q not found and there are no files sorted after it", lastFileNameUsed, path)
+			// Continue as if the last file of the list was the last one used
+			lastFileNameUsed = fileList[len(fileList)-1]
+		case position == 0:
+			p.logf("last file %q of path %q not found, resuming from %q", lastFileNameUsed, path, fileList[position])
+			lastFileNameUsed = ""
+		default:
+			p.logf("last file %q of path %q not found, resuming from %q", lastFileNameUsed, path, fileList[position])
+			lastFileNameUsed = fileList[position-1]
 		}
+	}
+
+	var nextFiles []string
+
+	// If the last file name used if the same of the last file list, it means that there is no more file to list
+	if lastFileNameUsed != fileList[len(fileList)-1] {
+		// Get n count file names after the last file name used
+		nextFiles = GetNextFiles(fileList, count, lastFileNameUsed)
+	}
+
+	if len(nextFiles) < count {
+		switch p.OnEnd {
+		case EndModeLoop:
+			// Fill the remainder of the count restarting from the first file
+			for len(nextFiles) < count {
+				// Every file was returned, so the next round follows a new permutation
+				if listing.shuffled() {
+					state.rotateShuffleSeed()
+					fileList = listing.sorted(state.ShuffleSeed)
+				}
 
-		// Filter file by the extension given
-		for _, fileExtension := range filterExtensions {
-			if filepath.Ext(f.Name()) == fileExtension {
-				paths = append(paths, path)
+				nextFiles = append(nextFiles, GetNextFiles(fileList, count-len(nextFiles), "")...)
+			}
+		case EndModeError:
+			if len(nextFiles) == 0 {
+				return nil, fmt.Errorf("%w: %s", ErrPlaylistEnded, path)
 			}
 		}
+	}
 
-		return nil
-	}); err != nil {
-		return nil, err
+	if len(nextFiles) == 0 {
+		return nil, nil
 	}
 
-	// Sort file path alphabetically
-	sort.Slice(paths, func(i, j int) bool {
-		return paths[i] < paths[j]
-	})
+	// Save the last file used on the state store
+	state.setLast(nextFiles[len(nextFiles)-1], resumeHints(listing.sortOrder))
 
-	return paths, nil
+	return nextFiles, nil
 }
 
-// ListFilesByDateCreation lists file path sorted by timestamp creation ascendant on the given path
-// and filter them with extension given.
-func ListFilesByDateCreation(path string, filterExtensions []string) ([]string, error) {
-	const timestampFileNameSeparator = "---"
+// containsFile returns whether the file path given is on the file list given.
+func containsFile(fileList []string, filePath string) bool {
+	return indexOfFile(fileList, filePath) >= 0
+}
 
-	var paths []string
+// findMissingFilePosition returns the position of the first file on the file list given which is sorted after
+// the last file of the state given under the sort order given. It is used when the last file doesn't exist anymore.
+// If there is no file sorted after it, the file list length is returned.
+func findMissingFilePosition(fileList []string, state State, sortOrder SortOrder) int {
+	sorter, err := newFileSorter(sortOrder, state.ShuffleSeed)
 
-	// Walks on the path given finding all the find names and filter them by the extension given
-	if err := filepath.Walk(path, func(path string, f os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
+	// The timestamps sort modes only can be used if the timestamps were saved,
+	// otherwise the file name is used as the best effort
+	if err != nil || (sorter.usesTimestamps() && state.LastModTime.IsZero()) {
+		sorter, _ = newFileSorter(FileSortModeFileNameAsc, 0)
+	}
 
-		// Ignore if it is a directory
-		if f.IsDir() {
-			return nil
-		}
+	lastFile := &sortFile{
+		path:      state.Last,
+		modTime:   &state.LastModTime,
+		birthTime: &state.LastBirthTime,
+		tags:      &readTags{tags: state.LastTags, found: state.LastTags != (Tags{})},
+		size:      &state.LastSize,
+		duration:  &readDuration{duration: state.LastDuration, found: state.LastDuration > 0},
+	}
 
-		// Filter file by the extension given
-		for _, fileExtension := range filterExtensions {
-			if filepath.Ext(f.Name()) == fileExtension {
-				// Append modification timestamp as prefix in order to sort the file path by date time modification
-				paths = append(paths, f.ModTime().String()+timestampFileNameSeparator+path)
-			}
+	// The last file saved by previous versions has no birth time
+	if state.LastBirthTime.IsZero() {
+		lastFile.birthTime = &state.LastModTime
+	}
+
+	for i, file := range fileList {
+		if sorter.compare(newSortFile(file, nil), lastFile) > 0 {
+			return i
 		}
+	}
 
-		return nil
-	}); err != nil {
-		return nil, err
+	return len(fileList)
+}
+
+// fileModTime returns the modification time of the file path given.
+// If the file can't be read, the zero time is returned.
+func fileModTime(filePath string) time.Time {
+	f, err := os.Lstat(filePath)
+	if err != nil {
+		return time.Time{}
+	}
+
+	return f.ModTime()
+}
+
+// fileTags returns the tags of the file path given. If the tags can't be read, empty tags are returned.
+func fileTags(filePath string) Tags {
+	tags, err := ReadTags(filePath)
+	if err != nil {
+		return Tags{}
 	}
 
-	// Sort file path alphabetically
-	sort.Slice(paths, func(i, j int) bool {
-		return paths[i] < paths[j]
-	})
+	return tags
+}
 
-	for i := range paths {
-		// Remove the file timestamp creation prefix in order to restore the origin file name
-		paths[i] = paths[i][strings.Index(paths[i], timestampFileNameSeparator)+len(timestampFileNameSeparator):]
+// fileSize returns the size of the file path given. If the file can't be read, zero is returned.
+func fileSize(filePath string) int64 {
+	f, err := os.Stat(filePath)
+	if err != nil {
+		return 0
 	}
 
-	return paths, nil
+	return f.Size()
 }
 
 // GetNextFiles return the count given file path names from the file list given after the from the file path given.
@@ -222,3 +464,13 @@ func GetNextFiles(fileList []string, count int, fromFilePath string) []string {
 
 	return filePaths
 }
+
+// fileDuration returns the media duration of the file path given. If the duration can't be read, zero is returned.
+func fileDuration(filePath string) time.Duration {
+	duration, err := ReadDuration(filePath)
+	if err != nil {
+		return 0
+	}
+
+	return duration
+}
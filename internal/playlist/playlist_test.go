@@ -1,7 +1,10 @@
 package playlist_test
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"log"
 	"os"
 	"path/filepath"
 	"testing"
@@ -103,11 +106,157 @@ func TestGetNextFiles(t *testing.T) {
 
 func TestPlaylistSortByUnknownMode(t *testing.T) {
 	client := playlist.Playlist{}
-	got, err := client.GetNextFilesFromPath("testdata/example_1", 3, []string{".ext"}, 100000)
+	got, err := client.GetNextFilesFromPath("testdata/example_1", 3, []string{".ext"}, playlist.FileSortMode(100000))
 	require.EqualValues(t, fmt.Errorf("%w: %d", playlist.ErrUnsupportedFileSortMode, 100000), err)
 	require.Empty(t, got)
 }
 
+func TestPlaylistResumeFromMissingLastFile(t *testing.T) {
+	var logOutput bytes.Buffer
+
+	store := playlist.NewMemoryStateStore()
+	client := playlist.Playlist{Store: store, Logger: log.New(&logOutput, "", 0)}
+
+	// The last file was deleted or renamed, it resumes from where it would have been sorted
+	require.NoError(t, store.Save("testdata/example_1", playlist.State{Last: "testdata/example_1/dir_2/file_2_15.ext"}))
+
+	got, err := client.GetNextFilesFromPath("testdata/example_1", 2, []string{".ext"}, playlist.FileSortModeFileNameAsc)
+	require.NoError(t, err)
+	require.EqualValues(t, []string{
+		"testdata/example_1/dir_2/file_2_2.ext",
+		"testdata/example_1/dir_3/file_3_1.ext",
+	}, got)
+	require.Contains(t, logOutput.String(),
+		`last file "testdata/example_1/dir_2/file_2_15.ext" of path "testdata/example_1" not found, `+
+			`resuming from "testdata/example_1/dir_2/file_2_2.ext"`)
+
+	// The last file was sorted before every file, it starts from the beginning
+	require.NoError(t, store.Save("testdata/example_1", playlist.State{Last: "testdata/example_1/dir_0/file_0_1.ext"}))
+
+	got, err = client.GetNextFilesFromPath("testdata/example_1", 1, []string{".ext"}, playlist.FileSortModeFileNameAsc)
+	require.NoError(t, err)
+	require.EqualValues(t, []string{"testdata/example_1/dir_1/file_1_1.ext"}, got)
+
+	// The last file was sorted after every file, there are no more files
+	require.NoError(t, store.Save("testdata/example_1", playlist.State{Last: "testdata/example_1/dir_4/file_4_1.ext"}))
+
+	var emptyList []string
+
+	got, err = client.GetNextFilesFromPath("testdata/example_1", 1, []string{".ext"}, playlist.FileSortModeFileNameAsc)
+	require.NoError(t, err)
+	require.EqualValues(t, emptyList, got)
+	require.Contains(t, logOutput.String(), "there are no files sorted after it")
+}
+
+func TestPlaylistResumeFromMissingLastFileByTimestampCreation(t *testing.T) {
+	testCaseName := "TestPlaylistResumeFromMissingLastFileByTimestampCreation"
+	exampleDirectoryPath, clearFunc := createFileTimestampCreationShortTestDataExample(t, testCaseName)
+
+	defer clearFunc()
+
+	store := playlist.NewMemoryStateStore()
+	client := playlist.Playlist{Store: store}
+
+	const sortMode playlist.FileSortMode = playlist.FileSortModeTimestampCreationAsc
+
+	got, err := client.GetNextFilesFromPath(exampleDirectoryPath, 2, []string{".ext", ".ext2"}, sortMode)
+	require.NoError(t, err)
+	require.EqualValues(t, []string{
+		exampleDirectoryPath + "/00_4.ext",
+		exampleDirectoryPath + "/00_2.ext",
+	}, got)
+
+	// The last file is deleted, so it resumes after its modification time
+	require.NoError(t, os.Remove(exampleDirectoryPath+"/00_2.ext"))
+
+	got, err = client.GetNextFilesFromPath(exampleDirectoryPath, 1, []string{".ext", ".ext2"}, sortMode)
+	require.NoError(t, err)
+	require.EqualValues(t, []string{exampleDirectoryPath + "/00_1.ext"}, got)
+}
+
+func TestPlaylistOnEnd(t *testing.T) {
+	extensions := []string{".ext"}
+
+	var emptyList []string
+
+	// Stop mode returns an empty list once the end is reached
+	client := playlist.Playlist{Store: playlist.NewMemoryStateStore()}
+
+	got, err := client.GetNextFilesFromPath("testdata/example_1", 7, extensions, playlist.FileSortModeFileNameAsc)
+	require.NoError(t, err)
+	require.Len(t, got, 7)
+
+	got, err = client.GetNextFilesFromPath("testdata/example_1", 3, extensions, playlist.FileSortModeFileNameAsc)
+	require.NoError(t, err)
+	require.EqualValues(t, emptyList, got)
+
+	// Loop mode restarts from the first file filling the remainder of the count
+	client = playlist.Playlist{Store: playlist.NewMemoryStateStore(), OnEnd: playlist.EndModeLoop}
+
+	got, err = client.GetNextFilesFromPath("testdata/example_1", 6, extensions, playlist.FileSortModeFileNameAsc)
+	require.NoError(t, err)
+	require.Len(t, got, 6)
+
+	got, err = client.GetNextFilesFromPath("testdata/example_1", 3, extensions, playlist.FileSortModeFileNameAsc)
+	require.NoError(t, err)
+	require.EqualValues(t, []string{
+		"testdata/example_1/dir_3/file_3_2.ext",
+		"testdata/example_1/dir_1/file_1_1.ext",
+		"testdata/example_1/dir_1/file_1_2.ext",
+	}, got)
+
+	got, err = client.GetNextFilesFromPath("testdata/example_1", 1, extensions, playlist.FileSortModeFileNameAsc)
+	require.NoError(t, err)
+	require.EqualValues(t, []string{"testdata/example_1/dir_1/file_1_3.ext"}, got)
+
+	// Error mode returns the partial list and then the playlist ended error
+	client = playlist.Playlist{Store: playlist.NewMemoryStateStore(), OnEnd: playlist.EndModeError}
+
+	got, err = client.GetNextFilesFromPath("testdata/example_1", 6, extensions, playlist.FileSortModeFileNameAsc)
+	require.NoError(t, err)
+	require.Len(t, got, 6)
+
+	got, err = client.GetNextFilesFromPath("testdata/example_1", 3, extensions, playlist.FileSortModeFileNameAsc)
+	require.NoError(t, err)
+	require.EqualValues(t, []string{"testdata/example_1/dir_3/file_3_2.ext"}, got)
+
+	got, err = client.GetNextFilesFromPath("testdata/example_1", 3, extensions, playlist.FileSortModeFileNameAsc)
+	require.True(t, errors.Is(err, playlist.ErrPlaylistEnded))
+	require.Empty(t, got)
+}
+
+func TestPlaylistPeekNextFilesFromPath(t *testing.T) {
+	store := playlist.NewMemoryStateStore()
+	client := playlist.Playlist{Store: store}
+
+	expected := []string{
+		"testdata/example_1/dir_1/file_1_1.ext",
+		"testdata/example_1/dir_1/file_1_2.ext",
+	}
+
+	// Peeking several times returns the same files without saving the state
+	for i := 0; i < 2; i++ {
+		got, err := client.PeekNextFilesFromPath("testdata/example_1", 2, []string{".ext"}, playlist.FileSortModeFileNameAsc)
+		require.NoError(t, err)
+		require.EqualValues(t, expected, got)
+	}
+
+	state, err := store.Load("testdata/example_1")
+	require.NoError(t, err)
+	require.EqualValues(t, playlist.State{}, state)
+
+	got, err := client.GetNextFilesFromPath("testdata/example_1", 2, []string{".ext"}, playlist.FileSortModeFileNameAsc)
+	require.NoError(t, err)
+	require.EqualValues(t, expected, got)
+
+	got, err = client.PeekNextFilesFromPath("testdata/example_1", 1, []string{".ext"}, playlist.FileSortModeFileNameAsc)
+	require.NoError(t, err)
+	require.EqualValues(t, []string{"testdata/example_1/dir_1/file_1_3.ext"}, got)
+
+	_, err = client.PeekNextFilesFromPath("testdata/example_1", 1, []string{".ext"}, playlist.FileSortMode(100000))
+	require.True(t, errors.Is(err, playlist.ErrUnsupportedFileSortMode))
+}
+
 func TestPlaylistFunctional(t *testing.T) {
 	t.Run("testPlaylistSortByFileNameAscFunctional", testPlaylistSortByFileNameAscFunctional)
 	t.Run("testPlaylistSortByFileTimestampCreationAscFunctional", testPlaylistSortByFileTimestampCreationAscFunctional)
@@ -120,6 +269,7 @@ func testPlaylistSortByFileNameAscFunctional(t *testing.T) {
 
 	defer func() {
 		require.NoError(t, os.Remove("cfg.ini"))
+		_ = os.Remove("cfg.ini.lock")
 	}()
 
 	client := playlist.Playlist{}
@@ -159,6 +309,7 @@ func testPlaylistSortByFileTimestampCreationAscFunctional(t *testing.T) {
 
 	defer func() {
 		require.NoError(t, os.Remove("cfg.ini"))
+		_ = os.Remove("cfg.ini.lock")
 	}()
 
 	testCaseName := "testPlaylistSortByFileTimestampCreationAscFunctional"
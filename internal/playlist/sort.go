@@ -0,0 +1,523 @@
+package playlist
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// A SortOrder represents how the files are sorted. It is either a FileSortMode, a SortSpec or a SortKeyRegexp.
+type SortOrder interface {
+	// sortKeys returns the keys compared in order to sort the files.
+	sortKeys() []SortKey
+}
+
+// SortKey represents a key of a sort specification.
+type SortKey struct {
+	// Mode is the file sort mode compared by the key.
+	Mode FileSortMode
+
+	// Descending defines whether the key is sorted descendant instead of ascendant.
+	Descending bool
+
+	// Regexp is the sort key regexp compared by the FileSortModeRegexp mode. It is required by that mode only.
+	Regexp *SortKeyRegexp
+}
+
+// SortSpec represents a sort specification which sorts the files by its first key,
+// the files equal on the first key by its second key and so on.
+// The files equal on every key are sorted by file path ascendant.
+type SortSpec []SortKey
+
+func (m FileSortMode) sortKeys() []SortKey {
+	return []SortKey{{Mode: m}}
+}
+
+func (s SortSpec) sortKeys() []SortKey {
+	return s
+}
+
+// sortFile represents a file being sorted. Its attributes are read only when a sort key needs them.
+type sortFile struct {
+	path      string
+	modTime   *time.Time
+	birthTime *time.Time
+	episode   *parsedEpisode
+	captures  map[*SortKeyRegexp]capturedValues
+	tags      *readTags
+	size      *int64
+	duration  *readDuration
+}
+
+// readDuration represents the result of reading the duration of a file.
+type readDuration struct {
+	duration time.Duration
+	found    bool
+}
+
+// readTags represents the result of reading the tags of a file.
+type readTags struct {
+	tags  Tags
+	found bool
+}
+
+// capturedValues represents the values captured on a file name by a sort key regexp.
+type capturedValues struct {
+	values  []string
+	matched bool
+}
+
+// parsedEpisode represents the result of parsing the episode of a file name.
+type parsedEpisode struct {
+	episode Episode
+	found   bool
+}
+
+// newSortFile returns the sort file of the file path given. If the file info given is not nil,
+// the modification time and the size are taken from it.
+func newSortFile(path string, info os.FileInfo) *sortFile {
+	file := &sortFile{path: path}
+
+	if info != nil {
+		modTime, size := info.ModTime(), info.Size()
+		file.modTime, file.size = &modTime, &size
+	}
+
+	return file
+}
+
+// getModTime returns the modification time of the file.
+func (f *sortFile) getModTime() time.Time {
+	if f.modTime == nil {
+		modTime := fileModTime(f.path)
+		f.modTime = &modTime
+	}
+
+	return *f.modTime
+}
+
+// getBirthTime returns the birth time of the file or its modification time when it can't be read.
+func (f *sortFile) getBirthTime() time.Time {
+	if f.birthTime == nil {
+		birthTime := fileBirthTime(f.path)
+		f.birthTime = &birthTime
+	}
+
+	return *f.birthTime
+}
+
+// getEpisode returns the episode parsed from the file name and whether it was found.
+func (f *sortFile) getEpisode() (Episode, bool) {
+	if f.episode == nil {
+		episode, found := ParseEpisode(f.path)
+		f.episode = &parsedEpisode{episode: episode, found: found}
+	}
+
+	return f.episode.episode, f.episode.found
+}
+
+// getTags returns the tags of the file and whether they were read.
+func (f *sortFile) getTags() (Tags, bool) {
+	if f.tags == nil {
+		tags, err := ReadTags(f.path)
+		f.tags = &readTags{tags: tags, found: err == nil}
+	}
+
+	return f.tags.tags, f.tags.found
+}
+
+// getSize returns the size of the file.
+func (f *sortFile) getSize() int64 {
+	if f.size == nil {
+		size := fileSize(f.path)
+		f.size = &size
+	}
+
+	return *f.size
+}
+
+// getDuration returns the media duration of the file and whether it was read.
+func (f *sortFile) getDuration() (time.Duration, bool) {
+	if f.duration == nil {
+		duration, err := ReadDuration(f.path)
+		f.duration = &readDuration{duration: duration, found: err == nil}
+	}
+
+	return f.duration.duration, f.duration.found
+}
+
+// getCaptures returns the values captured on the file name by the sort key regexp given
+// and whether it matched the file name.
+func (f *sortFile) getCaptures(keyRegexp *SortKeyRegexp) ([]string, bool) {
+	captured, found := f.captures[keyRegexp]
+	if !found {
+		if f.captures == nil {
+			f.captures = make(map[*SortKeyRegexp]capturedValues)
+		}
+
+		captured.values, captured.matched = keyRegexp.capture(f.path)
+		f.captures[keyRegexp] = captured
+	}
+
+	return captured.values, captured.matched
+}
+
+// fileComparator compares the files given returning a negative number when a is sorted before b,
+// a positive number when a is sorted after b and zero when they are equal.
+type fileComparator func(a, b *sortFile) int
+
+// fileComparators returns the comparator of every file sort mode supported.
+// The shuffle seed given defines the permutation of the shuffle sort mode.
+func fileComparators(shuffleSeed uint64) map[FileSortMode]fileComparator {
+	return map[FileSortMode]fileComparator{
+		FileSortModeFileNameAsc: func(a, b *sortFile) int {
+			return strings.Compare(a.path, b.path)
+		},
+		FileSortModeNaturalAsc: func(a, b *sortFile) int {
+			return compareNatural(a.path, b.path)
+		},
+		FileSortModeTimestampCreationAsc: func(a, b *sortFile) int {
+			return compareTimes(a.getModTime(), b.getModTime())
+		},
+		FileSortModeBirthTimeAsc: func(a, b *sortFile) int {
+			return compareTimes(a.getBirthTime(), b.getBirthTime())
+		},
+		FileSortModeDirectoryAsc: func(a, b *sortFile) int {
+			return compareNatural(filepath.Dir(a.path), filepath.Dir(b.path))
+		},
+		FileSortModeSizeAsc: func(a, b *sortFile) int {
+			return compareInts(a.getSize(), b.getSize())
+		},
+		FileSortModeEpisodeAsc:  compareEpisodes,
+		FileSortModeTagsAsc:     compareTags,
+		FileSortModeDurationAsc: compareDurations,
+		FileSortModeInterleave: func(a, b *sortFile) int {
+			// The files are interleaved once sorted by the rest of keys
+			return 0
+		},
+		FileSortModeShuffle: func(a, b *sortFile) int {
+			return compareUints(shuffleKey(shuffleSeed, a.path), shuffleKey(shuffleSeed, b.path))
+		},
+	}
+}
+
+// fileSorter sorts files following a sort order.
+type fileSorter struct {
+	keys        []SortKey
+	comparators []fileComparator
+}
+
+// newFileSorter returns the file sorter of the sort order given shuffling the files by the shuffle seed given.
+// If a file sort mode of the sort order is unknown, ErrUnsupportedFileSortMode is returned.
+func newFileSorter(sortOrder SortOrder, shuffleSeed uint64) (fileSorter, error) {
+	if sortOrder == nil || len(sortOrder.sortKeys()) == 0 {
+		return fileSorter{}, fmt.Errorf("%w: empty sort specification", ErrUnsupportedFileSortMode)
+	}
+
+	comparators := fileComparators(shuffleSeed)
+	sorter := fileSorter{keys: sortOrder.sortKeys()}
+
+	for _, key := range sorter.keys {
+		if key.Mode == FileSortModeRegexp {
+			if key.Regexp == nil {
+				return fileSorter{}, fmt.Errorf("%w: regexp sort mode without sort key regexp", ErrUnsupportedFileSortMode)
+			}
+
+			sorter.comparators = append(sorter.comparators, key.Regexp.compare)
+
+			continue
+		}
+
+		comparator, found := comparators[key.Mode]
+		if !found {
+			return fileSorter{}, fmt.Errorf("%w: %d", ErrUnsupportedFileSortMode, key.Mode)
+		}
+
+		sorter.comparators = append(sorter.comparators, comparator)
+	}
+
+	return sorter, nil
+}
+
+// compare compares the files given by every key of the sort order and by file path as the last resort.
+func (s fileSorter) compare(a, b *sortFile) int {
+	for i, comparator := range s.comparators {
+		result := comparator(a, b)
+		if result == 0 {
+			continue
+		}
+
+		if s.keys[i].Descending {
+			return -result
+		}
+
+		return result
+	}
+
+	return strings.Compare(a.path, b.path)
+}
+
+// sort sorts the files given.
+func (s fileSorter) sort(files []*sortFile) {
+	sort.SliceStable(files, func(i, j int) bool {
+		return s.compare(files[i], files[j]) < 0
+	})
+}
+
+// usesTimestamps returns whether a key of the sort order compares the file timestamps.
+func (s fileSorter) usesTimestamps() bool {
+	for _, key := range s.keys {
+		if key.Mode == FileSortModeTimestampCreationAsc || key.Mode == FileSortModeBirthTimeAsc {
+			return true
+		}
+	}
+
+	return false
+}
+
+// usesShuffle returns whether a key of the sort order shuffles the files.
+func (s fileSorter) usesShuffle() bool {
+	for _, key := range s.keys {
+		if key.Mode == FileSortModeShuffle {
+			return true
+		}
+	}
+
+	return false
+}
+
+// usesInterleave returns whether a key of the sort order interleaves the files.
+func (s fileSorter) usesInterleave() bool {
+	for _, key := range s.keys {
+		if key.Mode == FileSortModeInterleave {
+			return true
+		}
+	}
+
+	return false
+}
+
+// fileListing represents the files found on a path which are sorted by a sort order.
+// The files are sorted once the shuffle seed is known, since it is part of the resume state.
+type fileListing struct {
+	path      string
+	sortOrder SortOrder
+	files     []*sortFile
+}
+
+// newFileListing returns the listing of the files found on the path given filtered by the extensions given
+// to be sorted by the sort order given. If a file sort mode of the sort order is unknown,
+// ErrUnsupportedFileSortMode is returned.
+func newFileListing(path string, filterExtensions []string, sortOrder SortOrder) (fileListing, error) {
+	if _, err := newFileSorter(sortOrder, 0); err != nil {
+		return fileListing{}, err
+	}
+
+	files, err := walkFiles(path, filterExtensions)
+	if err != nil {
+		return fileListing{}, err
+	}
+
+	return fileListing{path: path, sortOrder: sortOrder, files: files}, nil
+}
+
+// sorted returns the file paths of the listing sorted by its sort order shuffling them by the shuffle seed given.
+// If the sort order interleaves the files, they are interleaved by top-level subdirectory.
+func (l fileListing) sorted(shuffleSeed uint64) []string {
+	fileList := l.sortedFiles(shuffleSeed)
+
+	if l.interleaved() {
+		groups, _ := interleaveGroups(l.path, fileList)
+		return interleave(groups)
+	}
+
+	return fileList
+}
+
+// sortedFiles returns the file paths of the listing sorted by its sort order shuffling them by the shuffle seed
+// given without interleaving them.
+func (l fileListing) sortedFiles(shuffleSeed uint64) []string {
+	// The sort order was validated creating the listing
+	sorter, _ := newFileSorter(l.sortOrder, shuffleSeed)
+
+	files := append([]*sortFile(nil), l.files...)
+	sorter.sort(files)
+
+	return filePaths(files)
+}
+
+// shuffled returns whether the sort order of the listing shuffles the files.
+func (l fileListing) shuffled() bool {
+	// The sort order was validated creating the listing
+	sorter, _ := newFileSorter(l.sortOrder, 0)
+
+	return sorter.usesShuffle()
+}
+
+// interleaved returns whether the sort order of the listing interleaves the files.
+func (l fileListing) interleaved() bool {
+	return isInterleaved(l.sortOrder)
+}
+
+// isInterleaved returns whether the sort order given interleaves the files.
+func isInterleaved(sortOrder SortOrder) bool {
+	sorter, err := newFileSorter(sortOrder, 0)
+
+	return err == nil && sorter.usesInterleave()
+}
+
+// walkFiles returns the files found walking the path given filtered by the extensions given.
+func walkFiles(path string, filterExtensions []string) ([]*sortFile, error) {
+	var files []*sortFile
+
+	// Walks on the path given finding all the find names and filter them by the extension given
+	if err := filepath.Walk(path, func(path string, f os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		// Ignore if it is a directory
+		if f.IsDir() {
+			return nil
+		}
+
+		// Filter file by the extension given
+		for _, fileExtension := range filterExtensions {
+			if filepath.Ext(f.Name()) == fileExtension {
+				files = append(files, newSortFile(path, f))
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return files, nil
+}
+
+// filePaths returns the paths of the files given.
+func filePaths(files []*sortFile) []string {
+	var paths []string
+
+	for _, file := range files {
+		paths = append(paths, file.path)
+	}
+
+	return paths
+}
+
+// compareTimes compares the times given returning a negative number when a is before b,
+// a positive number when a is after b and zero when they are equal.
+func compareTimes(a, b time.Time) int {
+	switch {
+	case a.Before(b):
+		return -1
+	case a.After(b):
+		return 1
+	default:
+		return 0
+	}
+}
+
+// compareInts compares the numbers given returning a negative number when a is lower than b,
+// a positive number when a is greater than b and zero when they are equal.
+func compareInts(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// compareUints compares the numbers given returning a negative number when a is lower than b,
+// a positive number when a is greater than b and zero when they are equal.
+func compareUints(a, b uint64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// shuffleKey returns the key which sorts the file path given under the shuffle seed given.
+// Hashing every path on its own keeps the order of the rest of files when a file is added or removed,
+// so the resume position is not lost.
+func shuffleKey(shuffleSeed uint64, filePath string) uint64 {
+	hash := fnv.New64a()
+
+	var seed [8]byte
+	binary.LittleEndian.PutUint64(seed[:], shuffleSeed)
+
+	// Writing on a hash never fails
+	_, _ = hash.Write(seed[:])
+	_, _ = hash.Write([]byte(filePath))
+
+	// The splitmix64 finalizer spreads the last bytes of the path, which FNV leaves on the lowest bits
+	key := hash.Sum64()
+	key = (key ^ (key >> 30)) * 0xbf58476d1ce4e5b9
+	key = (key ^ (key >> 27)) * 0x94d049bb133111eb
+
+	return key ^ (key >> 31)
+}
+
+// randomShuffleSeed returns a random shuffle seed. It is never zero, since zero means no seed was chosen yet.
+func randomShuffleSeed() uint64 {
+	var seed [8]byte
+
+	for {
+		if _, err := rand.Read(seed[:]); err != nil {
+			// The clock is the best effort when the system random source fails
+			binary.LittleEndian.PutUint64(seed[:], uint64(time.Now().UnixNano()))
+		}
+
+		if shuffleSeed := binary.LittleEndian.Uint64(seed[:]); shuffleSeed != 0 {
+			return shuffleSeed
+		}
+	}
+}
+
+// pathShuffleSeeds returns the shuffle seeds used by the path given until they are saved on its state.
+// They are derived from the path, so listing the files without saving the state follows the same permutations
+// the next files will follow. They are never zero.
+func pathShuffleSeeds(path string) (shuffleSeed, nextShuffleSeed uint64) {
+	shuffleSeed, nextShuffleSeed = shuffleKey(1, path), shuffleKey(2, path)
+
+	if shuffleSeed == 0 {
+		shuffleSeed = 1
+	}
+
+	if nextShuffleSeed == 0 {
+		nextShuffleSeed = 2
+	}
+
+	return shuffleSeed, nextShuffleSeed
+}
+
+// FileBirthTime returns the birth time of the file path given and whether the platform and file system
+// record it. When they don't, the zero time is returned.
+func FileBirthTime(filePath string) (time.Time, bool) {
+	return birthTime(filePath)
+}
+
+// fileBirthTime returns the birth time of the file path given or its modification time when it can't be read.
+// If the file can't be read, the zero time is returned.
+func fileBirthTime(filePath string) time.Time {
+	if birthTime, ok := birthTime(filePath); ok {
+		return birthTime
+	}
+
+	return fileModTime(filePath)
+}
@@ -0,0 +1,202 @@
+package playlist
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// A SortKeyType represents how the values captured by a sort key regexp group are compared.
+type SortKeyType uint
+
+const (
+	// SortKeyTypeString represents the values compared as strings following the natural order.
+	SortKeyTypeString SortKeyType = iota
+
+	// SortKeyTypeNumber represents the values compared as decimal numbers.
+	SortKeyTypeNumber
+
+	// SortKeyTypeDate represents the values compared as dates, such as "2021-03-04", "2021_03_04",
+	// "2021.03.04", "20210304" or "2021-03-04T10:20:30Z".
+	SortKeyTypeDate
+)
+
+// _sortKeyDateLayouts are the layouts tried in order to parse the values of the date sort key type.
+var _sortKeyDateLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02_15-04-05",
+	"2006-01-02",
+	"2006_01_02",
+	"2006.01.02",
+	"20060102",
+	"2006-01",
+	"2006",
+}
+
+var (
+	// ErrInvalidSortKeyRegexp represent the error when a sort key regexp given can't be used.
+	ErrInvalidSortKeyRegexp = fmt.Errorf("invalid sort key regexp")
+)
+
+// SortKeyRegexp represents a sort key made of the values captured on the file names by the named groups
+// of a regular expression. The values are compared group by group in order following the type of every group.
+// If the regular expression has no named groups, the whole match is compared as a string.
+// The file names not matched are sorted after the rest following the natural order.
+type SortKeyRegexp struct {
+	re    *regexp.Regexp
+	types []SortKeyType
+}
+
+// NewSortKeyRegexp returns the sort key regexp of the regular expression given whose named groups are typed
+// in order by the types given. The groups without type are compared as strings.
+// If the expression is not valid or there are more types than named groups, ErrInvalidSortKeyRegexp is returned.
+func NewSortKeyRegexp(expr string, types ...SortKeyType) (*SortKeyRegexp, error) {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidSortKeyRegexp, err)
+	}
+
+	keyRegexp := &SortKeyRegexp{re: re}
+
+	for i, name := range re.SubexpNames() {
+		if i > 0 && name != "" {
+			keyRegexp.types = append(keyRegexp.types, SortKeyTypeString)
+		}
+	}
+
+	if len(types) > len(keyRegexp.types) {
+		return nil, fmt.Errorf("%w: %d types for %d named groups", ErrInvalidSortKeyRegexp,
+			len(types), len(keyRegexp.types))
+	}
+
+	copy(keyRegexp.types, types)
+
+	return keyRegexp, nil
+}
+
+// String returns the regular expression of the sort key regexp.
+func (r *SortKeyRegexp) String() string {
+	return r.re.String()
+}
+
+func (r *SortKeyRegexp) sortKeys() []SortKey {
+	return []SortKey{{Mode: FileSortModeRegexp, Regexp: r}}
+}
+
+// capture returns the values captured on the base name of the file path given
+// and whether the regular expression matched it.
+func (r *SortKeyRegexp) capture(filePath string) ([]string, bool) {
+	match := r.re.FindStringSubmatch(filepath.Base(filePath))
+	if match == nil {
+		return nil, false
+	}
+
+	// Without named groups the whole match is the only value
+	if len(r.types) == 0 {
+		return match[:1], true
+	}
+
+	var values []string
+
+	for i, name := range r.re.SubexpNames() {
+		if i > 0 && name != "" {
+			values = append(values, match[i])
+		}
+	}
+
+	return values, true
+}
+
+// compare compares the files given by the values captured on their names.
+func (r *SortKeyRegexp) compare(a, b *sortFile) int {
+	valuesA, okA := a.getCaptures(r)
+	valuesB, okB := b.getCaptures(r)
+
+	switch {
+	case okA && !okB:
+		return -1
+	case !okA && okB:
+		return 1
+	case !okA && !okB:
+		return compareNatural(a.path, b.path)
+	}
+
+	for i := range valuesA {
+		keyType := SortKeyTypeString
+		if i < len(r.types) {
+			keyType = r.types[i]
+		}
+
+		if result := compareSortKeyValues(valuesA[i], valuesB[i], keyType); result != 0 {
+			return result
+		}
+	}
+
+	return 0
+}
+
+// compareSortKeyValues compares the values given following the sort key type given.
+// The values which can't be parsed as the type are sorted after the rest following the natural order.
+func compareSortKeyValues(a, b string, keyType SortKeyType) int {
+	switch keyType {
+	case SortKeyTypeNumber:
+		numberA, errA := strconv.ParseFloat(a, 64)
+		numberB, errB := strconv.ParseFloat(b, 64)
+
+		if errA == nil && errB == nil {
+			switch {
+			case numberA < numberB:
+				return -1
+			case numberA > numberB:
+				return 1
+			default:
+				return 0
+			}
+		}
+
+		if result := compareParsed(errA == nil, errB == nil); result != 0 {
+			return result
+		}
+	case SortKeyTypeDate:
+		dateA, okA := parseSortKeyDate(a)
+		dateB, okB := parseSortKeyDate(b)
+
+		if okA && okB {
+			return compareTimes(dateA, dateB)
+		}
+
+		if result := compareParsed(okA, okB); result != 0 {
+			return result
+		}
+	}
+
+	return compareNatural(a, b)
+}
+
+// compareParsed sorts the value parsed before the value not parsed. If both or none were parsed, it returns zero.
+func compareParsed(okA, okB bool) int {
+	switch {
+	case okA && !okB:
+		return -1
+	case !okA && okB:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// parseSortKeyDate returns the date of the value given trying every date layout supported
+// and whether it was parsed.
+func parseSortKeyDate(value string) (time.Time, bool) {
+	for _, layout := range _sortKeyDateLayouts {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date, true
+		}
+	}
+
+	return time.Time{}, false
+}
@@ -0,0 +1,347 @@
+package playlist_test
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/masch/goplaylist/internal/playlist"
+)
+
+func TestListFilesFromPathBySortSpec(t *testing.T) {
+	directory, removeDirectory := createTemporaryDirectory(t)
+	defer removeDirectory()
+
+	baseTime := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for _, dir := range []string{"Week 2", "Week 10"} {
+		require.NoError(t, os.Mkdir(filepath.Join(directory, dir), 0o700))
+	}
+
+	files := []struct {
+		name    string
+		modTime time.Time
+	}{
+		{name: "Week 10/lesson 1.ext", modTime: baseTime},
+		{name: "Week 10/lesson 2.ext", modTime: baseTime.Add(time.Hour)},
+		{name: "Week 2/lesson 10.ext", modTime: baseTime},
+		{name: "Week 2/lesson 9.ext", modTime: baseTime.Add(time.Hour)},
+	}
+
+	for _, file := range files {
+		createFileWithTimes(t, filepath.Join(directory, file.name), file.modTime)
+	}
+
+	path := func(name string) string {
+		return filepath.Join(directory, name)
+	}
+
+	tt := []struct {
+		name     string
+		spec     playlist.SortSpec
+		expected []string
+		err      error
+	}{
+		{
+			name: "OK_directory_then_natural",
+			spec: playlist.SortSpec{{Mode: playlist.FileSortModeDirectoryAsc}, {Mode: playlist.FileSortModeNaturalAsc}},
+			expected: []string{
+				path("Week 2/lesson 9.ext"), path("Week 2/lesson 10.ext"),
+				path("Week 10/lesson 1.ext"), path("Week 10/lesson 2.ext"),
+			},
+		},
+		{
+			name: "OK_modification_time_descendant_then_name",
+			spec: playlist.SortSpec{
+				{Mode: playlist.FileSortModeModTimeAsc, Descending: true},
+				{Mode: playlist.FileSortModeFileNameAsc},
+			},
+			expected: []string{
+				path("Week 10/lesson 2.ext"), path("Week 2/lesson 9.ext"),
+				path("Week 10/lesson 1.ext"), path("Week 2/lesson 10.ext"),
+			},
+		},
+		{
+			name: "OK_name_descendant",
+			spec: playlist.SortSpec{{Mode: playlist.FileSortModeFileNameAsc, Descending: true}},
+			expected: []string{
+				path("Week 2/lesson 9.ext"), path("Week 2/lesson 10.ext"),
+				path("Week 10/lesson 2.ext"), path("Week 10/lesson 1.ext"),
+			},
+		},
+		{
+			name: "FAIL_unknown_mode",
+			spec: playlist.SortSpec{{Mode: playlist.FileSortModeNaturalAsc}, {Mode: 100000}},
+			err:  playlist.ErrUnsupportedFileSortMode,
+		},
+		{
+			name: "FAIL_empty",
+			spec: playlist.SortSpec{},
+			err:  playlist.ErrUnsupportedFileSortMode,
+		},
+	}
+
+	client := playlist.Playlist{Store: playlist.NewMemoryStateStore()}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := client.ListFilesFromPath(directory, []string{".ext"}, tc.spec)
+			require.True(t, errors.Is(err, tc.err), err)
+			require.EqualValues(t, tc.expected, got)
+		})
+	}
+}
+
+func TestPlaylistSortSpecResumeFromMissingLastFile(t *testing.T) {
+	directory, removeDirectory := createTemporaryDirectory(t)
+	defer removeDirectory()
+
+	baseTime := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// The newest files first
+	for i, fileName := range []string{"episode_1.ext", "episode_2.ext", "episode_3.ext", "episode_4.ext"} {
+		createFileWithTimes(t, filepath.Join(directory, fileName), baseTime.Add(time.Duration(i)*time.Hour))
+	}
+
+	spec := playlist.SortSpec{{Mode: playlist.FileSortModeModTimeAsc, Descending: true}}
+	client := playlist.Playlist{Store: playlist.NewMemoryStateStore()}
+
+	got, err := client.GetNextFilesFromPath(directory, 2, []string{".ext"}, spec)
+	require.NoError(t, err)
+	require.EqualValues(t, []string{
+		filepath.Join(directory, "episode_4.ext"),
+		filepath.Join(directory, "episode_3.ext"),
+	}, got)
+
+	// The last file is deleted, so it resumes with the next older file
+	require.NoError(t, os.Remove(filepath.Join(directory, "episode_3.ext")))
+
+	got, err = client.GetNextFilesFromPath(directory, 1, []string{".ext"}, spec)
+	require.NoError(t, err)
+	require.EqualValues(t, []string{filepath.Join(directory, "episode_2.ext")}, got)
+}
+
+func TestPlaylistShuffle(t *testing.T) {
+	directory, removeDirectory := createTemporaryDirectory(t)
+	defer removeDirectory()
+
+	var fileList []string
+
+	for i := 1; i <= 10; i++ {
+		fileName := filepath.Join(directory, fmt.Sprintf("song_%02d.ext", i))
+		createFileWithTimes(t, fileName, time.Now())
+		fileList = append(fileList, fileName)
+	}
+
+	store := playlist.NewIniStateStore(filepath.Join(directory, "state.ini"))
+	client := playlist.Playlist{Store: store, OnEnd: playlist.EndModeLoop}
+
+	require.NoError(t, store.Save(directory, playlist.State{ShuffleSeed: 1, NextShuffleSeed: 2}))
+
+	// Peeking shows the same files listed next
+	peeked, err := client.PeekNextFilesFromPath(directory, 4, []string{".ext"}, playlist.FileSortModeShuffle)
+	require.NoError(t, err)
+
+	firstRound, err := client.GetNextFilesFromPath(directory, 4, []string{".ext"}, playlist.FileSortModeShuffle)
+	require.NoError(t, err)
+	require.EqualValues(t, peeked, firstRound)
+	require.NotEqualValues(t, fileList[:4], firstRound)
+
+	// A new client resumes from the same permutation
+	client = playlist.Playlist{Store: playlist.NewIniStateStore(filepath.Join(directory, "state.ini"))}
+
+	got, err := client.GetNextFilesFromPath(directory, 6, []string{".ext"}, playlist.FileSortModeShuffle)
+	require.NoError(t, err)
+
+	firstRound = append(firstRound, got...)
+	require.ElementsMatch(t, fileList, firstRound)
+
+	got, err = client.ListFilesFromPath(directory, []string{".ext"}, playlist.FileSortModeShuffle)
+	require.NoError(t, err)
+	require.EqualValues(t, firstRound, got)
+
+	// Every file was listed, so the next round follows the next permutation, which peeking already shows
+	client.OnEnd = playlist.EndModeLoop
+
+	peeked, err = client.PeekNextFilesFromPath(directory, 10, []string{".ext"}, playlist.FileSortModeShuffle)
+	require.NoError(t, err)
+
+	secondRound, err := client.GetNextFilesFromPath(directory, 10, []string{".ext"}, playlist.FileSortModeShuffle)
+	require.NoError(t, err)
+	require.EqualValues(t, peeked, secondRound)
+	require.ElementsMatch(t, fileList, secondRound)
+	require.NotEqualValues(t, firstRound, secondRound)
+
+	got, err = client.ListFilesFromPath(directory, []string{".ext"}, playlist.FileSortModeShuffle)
+	require.NoError(t, err)
+	require.EqualValues(t, secondRound, got)
+
+	// The permutation after the next one is chosen randomly
+	state, err := store.Load(directory)
+	require.NoError(t, err)
+	require.EqualValues(t, 2, state.ShuffleSeed)
+	require.NotZero(t, state.NextShuffleSeed)
+	require.NotEqualValues(t, 2, state.NextShuffleSeed)
+
+	// Removing the last file listed resumes from the next file of the same permutation
+	require.NoError(t, client.SeekIndexFromPath(directory, 3, []string{".ext"}, playlist.FileSortModeShuffle))
+	require.NoError(t, os.Remove(secondRound[2]))
+
+	got, err = client.GetNextFilesFromPath(directory, 2, []string{".ext"}, playlist.FileSortModeShuffle)
+	require.NoError(t, err)
+	require.EqualValues(t, secondRound[3:5], got)
+}
+
+func TestPlaylistShuffleSeedIsSavedByAdvancing(t *testing.T) {
+	directory, removeDirectory := createTemporaryDirectory(t)
+	defer removeDirectory()
+
+	for i := 1; i <= 3; i++ {
+		createFileWithTimes(t, filepath.Join(directory, fmt.Sprintf("song_%02d.ext", i)), time.Now())
+	}
+
+	stateFileName := filepath.Join(directory, "state.ini")
+	store := playlist.NewIniStateStore(stateFileName)
+	client := playlist.Playlist{Store: store}
+	extensions := []string{".ext"}
+
+	// Reading the files doesn't save the shuffle seeds, but they follow the permutation the next files follow
+	listed, err := client.ListFilesFromPath(directory, extensions, playlist.FileSortModeShuffle)
+	require.NoError(t, err)
+
+	peeked, err := client.PeekNextFilesFromPath(directory, 3, extensions, playlist.FileSortModeShuffle)
+	require.NoError(t, err)
+	require.EqualValues(t, listed, peeked)
+
+	_, err = client.StatusFromPath(directory, extensions, playlist.FileSortModeShuffle)
+	require.NoError(t, err)
+
+	_, err = client.HistoryFromPath(directory, extensions, playlist.FileSortModeShuffle)
+	require.NoError(t, err)
+
+	_, err = os.Stat(stateFileName)
+	require.True(t, os.IsNotExist(err), err)
+
+	got, err := client.GetNextFilesFromPath(directory, 3, extensions, playlist.FileSortModeShuffle)
+	require.NoError(t, err)
+	require.EqualValues(t, listed, got)
+
+	first, err := store.Load(directory)
+	require.NoError(t, err)
+	require.NotZero(t, first.ShuffleSeed)
+	require.NotZero(t, first.NextShuffleSeed)
+
+	// Resetting the state moves to the next shuffle seed and chooses a new random seed after it
+	require.NoError(t, client.ResetFromPath(directory))
+
+	second, err := store.Load(directory)
+	require.NoError(t, err)
+	require.EqualValues(t, playlist.State{ShuffleSeed: first.NextShuffleSeed, NextShuffleSeed: second.NextShuffleSeed},
+		second)
+	require.NotZero(t, second.NextShuffleSeed)
+	require.NotEqualValues(t, first.NextShuffleSeed, second.NextShuffleSeed)
+}
+
+func TestListFilesFromPathBySortKeyRegexp(t *testing.T) {
+	directory, removeDirectory := createTemporaryDirectory(t)
+	defer removeDirectory()
+
+	fileNames := []string{
+		"intro.mp4",
+		"lecture-2021-02-28-part1.mp4",
+		"lecture-2021-03-04-part10.mp4",
+		"lecture-2021-03-04-part2.mp4",
+		"lecture-2021-03-04-partx.mp4",
+		"lecture-2021-3-4-part1.mp4",
+		"summary.mp4",
+	}
+
+	for _, fileName := range fileNames {
+		createFileWithTimes(t, filepath.Join(directory, fileName), time.Now())
+	}
+
+	paths := func(names ...string) []string {
+		var paths []string
+		for _, name := range names {
+			paths = append(paths, filepath.Join(directory, name))
+		}
+
+		return paths
+	}
+
+	keyRegexp, err := playlist.NewSortKeyRegexp(`(?P<date>\d{4}-\d+-\d+).*part(?P<n>\w+)`,
+		playlist.SortKeyTypeDate, playlist.SortKeyTypeNumber)
+	require.NoError(t, err)
+
+	stringKeyRegexp, err := playlist.NewSortKeyRegexp(`(?P<date>\d{4}-\d+-\d+).*part(?P<n>\w+)`)
+	require.NoError(t, err)
+
+	wholeMatchKeyRegexp, err := playlist.NewSortKeyRegexp(`part\w+`)
+	require.NoError(t, err)
+
+	tt := []struct {
+		name     string
+		sort     playlist.SortOrder
+		expected []string
+	}{
+		{
+			name: "OK_typed_groups",
+			sort: keyRegexp,
+			expected: paths(
+				"lecture-2021-02-28-part1.mp4", "lecture-2021-03-04-part2.mp4", "lecture-2021-03-04-part10.mp4",
+				"lecture-2021-03-04-partx.mp4", "lecture-2021-3-4-part1.mp4", "intro.mp4", "summary.mp4",
+			),
+		},
+		{
+			name: "OK_string_groups",
+			sort: stringKeyRegexp,
+			expected: paths(
+				"lecture-2021-02-28-part1.mp4", "lecture-2021-03-04-part2.mp4", "lecture-2021-03-04-part10.mp4",
+				"lecture-2021-03-04-partx.mp4", "lecture-2021-3-4-part1.mp4", "intro.mp4", "summary.mp4",
+			),
+		},
+		{
+			name: "OK_whole_match",
+			sort: wholeMatchKeyRegexp,
+			expected: paths(
+				"lecture-2021-02-28-part1.mp4", "lecture-2021-3-4-part1.mp4", "lecture-2021-03-04-part2.mp4",
+				"lecture-2021-03-04-part10.mp4", "lecture-2021-03-04-partx.mp4", "intro.mp4", "summary.mp4",
+			),
+		},
+		{
+			name: "OK_descendant",
+			sort: playlist.SortSpec{{Mode: playlist.FileSortModeRegexp, Descending: true, Regexp: keyRegexp}},
+			expected: paths(
+				"summary.mp4", "intro.mp4", "lecture-2021-3-4-part1.mp4", "lecture-2021-03-04-partx.mp4",
+				"lecture-2021-03-04-part10.mp4", "lecture-2021-03-04-part2.mp4", "lecture-2021-02-28-part1.mp4",
+			),
+		},
+	}
+
+	client := playlist.Playlist{Store: playlist.NewMemoryStateStore()}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := client.ListFilesFromPath(directory, []string{".mp4"}, tc.sort)
+			require.NoError(t, err)
+			require.EqualValues(t, tc.expected, got)
+		})
+	}
+
+	_, err = client.ListFilesFromPath(directory, []string{".mp4"},
+		playlist.SortSpec{{Mode: playlist.FileSortModeRegexp}})
+	require.True(t, errors.Is(err, playlist.ErrUnsupportedFileSortMode))
+
+	_, err = playlist.NewSortKeyRegexp(`(?P<n>\d+`)
+	require.True(t, errors.Is(err, playlist.ErrInvalidSortKeyRegexp))
+
+	_, err = playlist.NewSortKeyRegexp(`(?P<n>\d+)`, playlist.SortKeyTypeNumber, playlist.SortKeyTypeDate)
+	require.True(t, errors.Is(err, playlist.ErrInvalidSortKeyRegexp))
+}
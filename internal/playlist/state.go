@@ -0,0 +1,214 @@
+package playlist
+
+import (
+	"time"
+)
+
+// State represents the resume information persisted for a source path.
+type State struct {
+	// Last is the last file name returned from the source path.
+	Last string
+
+	// LastModTime is the modification time of the last file name returned.
+	// It is used to find where to resume when the last file was deleted or renamed.
+	LastModTime time.Time
+
+	// LastBirthTime is the birth time of the last file name returned, or its modification time
+	// when the birth time can't be read. It is used as LastModTime by the birth time sort mode.
+	LastBirthTime time.Time
+
+	// LastTags are the tags of the last file name returned, empty when it has no tags.
+	// They are used to find where to resume by the tags sort mode when the last file was deleted or renamed.
+	LastTags Tags
+
+	// LastSize is the size of the last file name returned. It is used to find where to resume
+	// by the size sort mode when the last file was deleted or renamed.
+	LastSize int64
+
+	// LastDuration is the media duration of the last file name returned, zero when it can't be read.
+	// It is used to find where to resume by the duration sort mode when the last file was deleted or renamed.
+	LastDuration time.Duration
+
+	// Pending are the file names reserved after the last file name which were not acknowledged yet.
+	Pending []string
+
+	// PendingSince is the time when the pending file names were reserved.
+	PendingSince time.Time
+
+	// ShuffleSeed is the seed of the permutation followed by the shuffle sort mode. It is derived from the source path
+	// the first time the shuffle sort mode is used and it moves to NextShuffleSeed every time the whole file list
+	// was returned. It is zero when it was not chosen yet.
+	ShuffleSeed uint64
+
+	// NextShuffleSeed is the random seed of the permutation which follows the current one. It is chosen in advance,
+	// so peeking the next files shows the same permutation the next files will use.
+	NextShuffleSeed uint64
+
+	// Cursors are the last file names returned from every top-level subdirectory by the interleave sort mode
+	// keyed by subdirectory name. It is nil when the interleave sort mode was not used.
+	Cursors map[string]Cursor
+
+	// ResumeHints are the resume hints saved of the last file name returned and of the cursors, chosen by the sort
+	// order which returned them. The acknowledgement of the pending files, which doesn't know the sort order,
+	// saves the same hints.
+	ResumeHints ResumeHints
+}
+
+// ResumeHints is a set of values of a file, besides its timestamps, which are saved on the state in order to
+// find where to resume when the file was deleted or renamed. Reading them can be expensive,
+// so only the values compared by the sort order are saved.
+type ResumeHints uint8
+
+const (
+	// ResumeHintTags saves the tags of the file used by the tags sort mode.
+	ResumeHintTags ResumeHints = 1 << iota
+
+	// ResumeHintSize saves the size of the file used by the size sort mode.
+	ResumeHintSize
+
+	// ResumeHintDuration saves the media duration of the file used by the duration sort mode.
+	ResumeHintDuration
+)
+
+// resumeHints returns the resume hints compared by the keys of the sort order given.
+func resumeHints(sortOrder SortOrder) ResumeHints {
+	var hints ResumeHints
+
+	for _, key := range sortOrder.sortKeys() {
+		switch key.Mode {
+		case FileSortModeTagsAsc:
+			hints |= ResumeHintTags
+		case FileSortModeSizeAsc:
+			hints |= ResumeHintSize
+		case FileSortModeDurationAsc:
+			hints |= ResumeHintDuration
+		}
+	}
+
+	return hints
+}
+
+// Cursor represents the last file name returned from a top-level subdirectory by the interleave sort mode.
+type Cursor struct {
+	// File is the last file name returned from the subdirectory.
+	File string
+
+	// ModTime is the modification time of the file. It is used to find where to resume
+	// when the file was deleted or renamed.
+	ModTime time.Time
+
+	// BirthTime is the birth time of the file, or its modification time when the birth time can't be read.
+	// It is used as ModTime by the birth time sort mode.
+	BirthTime time.Time
+
+	// Tags, Size and Duration are the values of the file saved by the resume hints of the state.
+	// They are used as the LastTags, LastSize and LastDuration of the state.
+	Tags     Tags
+	Size     int64
+	Duration time.Duration
+}
+
+// newCursor returns the cursor of the file name given saving its timestamps and the values of the resume hints
+// given.
+func newCursor(fileName string, hints ResumeHints) Cursor {
+	cursor := Cursor{File: fileName, ModTime: fileModTime(fileName), BirthTime: fileBirthTime(fileName)}
+
+	if hints&ResumeHintTags != 0 {
+		cursor.Tags = fileTags(fileName)
+	}
+
+	if hints&ResumeHintSize != 0 {
+		cursor.Size = fileSize(fileName)
+	}
+
+	if hints&ResumeHintDuration != 0 {
+		cursor.Duration = fileDuration(fileName)
+	}
+
+	return cursor
+}
+
+// state returns the state whose last file name is the cursor file following the permutation of the shuffle seed
+// given, so the cursor is resumed as the last file name is.
+func (c Cursor) state(shuffleSeed uint64) State {
+	return State{
+		Last:          c.File,
+		LastModTime:   c.ModTime,
+		LastBirthTime: c.BirthTime,
+		LastTags:      c.Tags,
+		LastSize:      c.Size,
+		LastDuration:  c.Duration,
+		ShuffleSeed:   shuffleSeed,
+	}
+}
+
+// setLast sets the file name given as the last file name returned saving its timestamps and the values
+// of the resume hints given. The values of the resume hints not given are cleared.
+func (s *State) setLast(fileName string, hints ResumeHints) {
+	last := newCursor(fileName, hints)
+
+	s.Last, s.LastModTime, s.LastBirthTime = last.File, last.ModTime, last.BirthTime
+	s.LastTags, s.LastSize, s.LastDuration = last.Tags, last.Size, last.Duration
+	s.ResumeHints = hints
+}
+
+// clone returns a copy of the state which doesn't share memory with it.
+func (s State) clone() State {
+	if s.Pending != nil {
+		s.Pending = append([]string(nil), s.Pending...)
+	}
+
+	if s.Cursors != nil {
+		cursors := make(map[string]Cursor, len(s.Cursors))
+		for group, cursor := range s.Cursors {
+			cursors[group] = cursor
+		}
+
+		s.Cursors = cursors
+	}
+
+	return s
+}
+
+// initShuffleSeeds sets the shuffle seeds derived from the source path given when they were not chosen yet.
+func (s *State) initShuffleSeeds(path string) {
+	shuffleSeed, nextShuffleSeed := pathShuffleSeeds(path)
+
+	if s.ShuffleSeed == 0 {
+		s.ShuffleSeed = shuffleSeed
+	}
+
+	if s.NextShuffleSeed == 0 {
+		s.NextShuffleSeed = nextShuffleSeed
+	}
+}
+
+// rotateShuffleSeed moves the state to the next shuffle seed choosing a new random seed to follow it.
+// The shuffle seeds must be chosen already.
+func (s *State) rotateShuffleSeed() {
+	s.ShuffleSeed, s.NextShuffleSeed = s.NextShuffleSeed, randomShuffleSeed()
+}
+
+// setCursor moves the cursor of the top-level subdirectory of the path given where the file name given is placed
+// to the file name given saving the resume hints of the state. It does nothing if the interleave sort mode
+// was not used.
+func (s *State) setCursor(path, fileName string) {
+	if s.Cursors != nil {
+		s.Cursors[interleaveGroup(path, fileName)] = newCursor(fileName, s.ResumeHints)
+	}
+}
+
+// A StateStore represents the mechanism to load and save the resume state of every source path.
+type StateStore interface {
+	// Load returns the state saved for the key given.
+	// If there is no state saved for the key, an empty state is returned.
+	Load(key string) (State, error)
+
+	// Save stores the state given for the key given.
+	Save(key string, state State) error
+
+	// Update loads the state of the key given, calls the update function given with it and saves
+	// the state modified by the function. No other update of the same store is run in the meantime.
+	// If the update function returns an error, the state is not saved and the error is returned.
+	Update(key string, update func(state *State) error) error
+}
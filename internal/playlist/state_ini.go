@@ -0,0 +1,358 @@
+package playlist
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"gopkg.in/ini.v1"
+)
+
+const (
+	_iniLockFileNameSuffix = ".lock"
+	_iniLastModTimeKey     = "last_mod_time"
+	_iniLastBirthTimeKey   = "last_birth_time"
+	_iniLastTagsKey        = "last_tags"
+	_iniLastSizeKey        = "last_size"
+	_iniLastDurationKey    = "last_duration"
+	_iniPendingKey         = "pending"
+	_iniPendingSinceKey    = "pending_since"
+	_iniShuffleSeedKey     = "shuffle_seed"
+	_iniNextShuffleSeedKey = "next_shuffle_seed"
+	_iniCursorsKey         = "cursors"
+	_iniResumeHintsKey     = "resume_hints"
+)
+
+// IniStateStore stores the resume state on an ini file using one section per source path.
+// Every write holds an advisory lock on a ".lock" file next to the ini file, so several processes can share
+// the same ini file, and it is done writing a temporary file which is renamed over the ini file,
+// so the ini file is never left truncated.
+type IniStateStore struct {
+	fileName       string
+	legacyFileName string
+	logger         *log.Logger
+}
+
+// NewIniStateStore returns a state store which persists the state on the ini file name given.
+func NewIniStateStore(fileName string) *IniStateStore {
+	return &IniStateStore{fileName: fileName}
+}
+
+// NewMigratingIniStateStore returns a state store which persists the state on the ini file name given
+// and migrates the legacy ini file name given, used by previous versions, to it on the first update.
+// Until then, the state is loaded from the legacy ini file. The migration is reported on the logger given
+// when it is not nil.
+func NewMigratingIniStateStore(fileName, legacyFileName string, logger *log.Logger) *IniStateStore {
+	return &IniStateStore{fileName: fileName, legacyFileName: legacyFileName, logger: logger}
+}
+
+// Load returns the state saved on the ini file section of the key given.
+// If the ini file doesn't exist, the legacy ini file is read instead when it is configured.
+// If none of them exist, an empty state is returned.
+func (s *IniStateStore) Load(key string) (State, error) {
+	fileName := s.fileName
+
+	// The legacy ini file is only read, it is moved by the updates which hold the lock
+	if _, err := os.Stat(fileName); os.IsNotExist(err) && s.legacyFileName != "" {
+		fileName = s.legacyFileName
+	}
+
+	cfg, err := ini.LooseLoad(fileName)
+	if err != nil {
+		return State{}, err
+	}
+
+	return readIniState(cfg.Section(key)), nil
+}
+
+// Save stores the state given on the ini file section of the key given
+// keeping the sections of the other keys untouched.
+// The directory of the ini file is created if it doesn't exist.
+func (s *IniStateStore) Save(key string, state State) error {
+	return s.Update(key, func(current *State) error {
+		*current = state
+		return nil
+	})
+}
+
+// Update loads the state of the ini file section of the key given, calls the update function given
+// with it and saves the result holding the ini file lock during the whole cycle.
+// The directory of the ini file is created if it doesn't exist.
+func (s *IniStateStore) Update(key string, update func(state *State) error) error {
+	if err := os.MkdirAll(filepath.Dir(s.fileName), _stateDirectoryPerm); err != nil {
+		return err
+	}
+
+	unlock, err := lockFile(s.fileName + _iniLockFileNameSuffix)
+	if err != nil {
+		return err
+	}
+	defer unlock() //nolint:errcheck // the lock is released closing the file even if the unlock fails
+
+	if s.legacyFileName != "" {
+		migrated, err := migrateStateFile(s.fileName, s.legacyFileName)
+		if err != nil {
+			return err
+		}
+
+		if migrated && s.logger != nil {
+			s.logger.Printf("state file %s migrated to %s", s.legacyFileName, s.fileName)
+		}
+	}
+
+	cfg, err := ini.LooseLoad(s.fileName)
+	if err != nil {
+		return err
+	}
+
+	state := readIniState(cfg.Section(key))
+	if err := update(&state); err != nil {
+		return err
+	}
+
+	writeIniState(cfg.Section(key), state)
+
+	return s.write(cfg)
+}
+
+// write saves the ini file given on a temporary file placed on the same directory
+// and then renames it to the ini file name in order to replace it atomically.
+func (s *IniStateStore) write(cfg *ini.File) error {
+	file, err := ioutil.TempFile(filepath.Dir(s.fileName), filepath.Base(s.fileName)+".*.tmp")
+	if err != nil {
+		return err
+	}
+
+	// Remove the temporary file if something fails, after the rename it doesn't exist anymore
+	defer os.Remove(file.Name()) //nolint:errcheck // the temporary file may have been renamed
+
+	if _, err := cfg.WriteTo(file); err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	if err := file.Sync(); err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(file.Name(), s.fileName)
+}
+
+// readIniState returns the state stored on the ini section given.
+// The pending file names are stored as a JSON array since file names can contain any character.
+// An invalid time value is ignored since it is only a hint to resume.
+func readIniState(section *ini.Section) State {
+	lastModTime, _ := time.Parse(time.RFC3339Nano, section.Key(_iniLastModTimeKey).String())
+	lastBirthTime, _ := time.Parse(time.RFC3339Nano, section.Key(_iniLastBirthTimeKey).String())
+	pendingSince, _ := time.Parse(time.RFC3339Nano, section.Key(_iniPendingSinceKey).String())
+
+	// An invalid shuffle seed is chosen again randomly
+	shuffleSeed, _ := strconv.ParseUint(section.Key(_iniShuffleSeedKey).String(), 10, 64)
+	nextShuffleSeed, _ := strconv.ParseUint(section.Key(_iniNextShuffleSeedKey).String(), 10, 64)
+
+	// Invalid resume hints are ignored since they are only a hint to resume
+	resumeHints, _ := strconv.ParseUint(section.Key(_iniResumeHintsKey).String(), 10, 8)
+
+	// An invalid size or duration is ignored since it is only a hint to resume
+	lastSize, _ := strconv.ParseInt(section.Key(_iniLastSizeKey).String(), 10, 64)
+	lastDuration, _ := time.ParseDuration(section.Key(_iniLastDurationKey).String())
+
+	// Invalid tags are ignored since they are only a hint to resume
+	var lastTags Tags
+	if value := section.Key(_iniLastTagsKey).String(); value != "" {
+		if err := json.Unmarshal([]byte(value), &lastTags); err != nil {
+			lastTags = Tags{}
+		}
+	}
+
+	var pending []string
+	if value := section.Key(_iniPendingKey).String(); value != "" {
+		// An invalid value means there is nothing pending
+		_ = json.Unmarshal([]byte(value), &pending)
+	}
+
+	var cursors map[string]Cursor
+	if value := section.Key(_iniCursorsKey).String(); value != "" {
+		cursors = readIniCursors(value)
+	}
+
+	return State{
+		Last:            section.Key(_iniLastFileNameProcessedSection).String(),
+		LastModTime:     lastModTime,
+		LastBirthTime:   lastBirthTime,
+		LastTags:        lastTags,
+		LastSize:        lastSize,
+		LastDuration:    lastDuration,
+		Pending:         pending,
+		PendingSince:    pendingSince,
+		ShuffleSeed:     shuffleSeed,
+		NextShuffleSeed: nextShuffleSeed,
+		Cursors:         cursors,
+		ResumeHints:     ResumeHints(resumeHints),
+	}
+}
+
+// writeIniState sets the state given on the ini section given.
+// Empty values are not written in order to keep the ini file readable.
+func writeIniState(section *ini.Section, state State) {
+	section.Key(_iniLastFileNameProcessedSection).SetValue(state.Last)
+
+	writeIniTime(section, _iniLastModTimeKey, state.LastModTime)
+	writeIniTime(section, _iniLastBirthTimeKey, state.LastBirthTime)
+	writeIniTime(section, _iniPendingSinceKey, state.PendingSince)
+
+	// The tags are stored as a JSON object since they can contain any character
+	if state.LastTags == (Tags{}) {
+		section.DeleteKey(_iniLastTagsKey)
+	} else {
+		// Marshaling the tags never fails
+		lastTags, _ := json.Marshal(state.LastTags)
+		section.Key(_iniLastTagsKey).SetValue(string(lastTags))
+	}
+
+	// The file sizes are never negative
+	writeIniUint(section, _iniLastSizeKey, uint64(state.LastSize))
+
+	// The duration is stored as a Go duration string, such as "3m25.5s", in order to keep it readable
+	if state.LastDuration > 0 {
+		section.Key(_iniLastDurationKey).SetValue(state.LastDuration.String())
+	} else {
+		section.DeleteKey(_iniLastDurationKey)
+	}
+
+	writeIniUint(section, _iniShuffleSeedKey, state.ShuffleSeed)
+	writeIniUint(section, _iniNextShuffleSeedKey, state.NextShuffleSeed)
+	writeIniUint(section, _iniResumeHintsKey, uint64(state.ResumeHints))
+
+	// The cursors are stored as a JSON object since file names can contain any character.
+	// An empty object is written in order to keep the cursors enabled.
+	if state.Cursors == nil {
+		section.DeleteKey(_iniCursorsKey)
+	} else {
+		section.Key(_iniCursorsKey).SetValue(writeIniCursors(state.Cursors))
+	}
+
+	if len(state.Pending) == 0 {
+		section.DeleteKey(_iniPendingKey)
+	} else {
+		// Marshaling a string slice never fails
+		pending, _ := json.Marshal(state.Pending)
+		section.Key(_iniPendingKey).SetValue(string(pending))
+	}
+}
+
+// iniCursor represents a cursor stored on the cursors JSON object of an ini section.
+// The empty values are omitted in order to keep the ini file readable.
+type iniCursor struct {
+	File      string `json:"file"`
+	ModTime   string `json:"mod_time,omitempty"`
+	BirthTime string `json:"birth_time,omitempty"`
+	Tags      *Tags  `json:"tags,omitempty"`
+	Size      int64  `json:"size,omitempty"`
+	Duration  string `json:"duration,omitempty"`
+}
+
+// readIniCursors returns the cursors of the JSON object given. The cursors saved by previous versions
+// are only file names. An invalid value means every subdirectory restarts from its first file.
+func readIniCursors(value string) map[string]Cursor {
+	var values map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(value), &values); err != nil {
+		return make(map[string]Cursor)
+	}
+
+	cursors := make(map[string]Cursor, len(values))
+
+	for group, raw := range values {
+		var stored iniCursor
+		if err := json.Unmarshal(raw, &stored.File); err != nil {
+			if err := json.Unmarshal(raw, &stored); err != nil {
+				continue
+			}
+		}
+
+		// An invalid time or duration value is ignored since it is only a hint to resume
+		modTime, _ := time.Parse(time.RFC3339Nano, stored.ModTime)
+		birthTime, _ := time.Parse(time.RFC3339Nano, stored.BirthTime)
+		duration, _ := time.ParseDuration(stored.Duration)
+
+		cursor := Cursor{File: stored.File, ModTime: modTime, BirthTime: birthTime, Size: stored.Size, Duration: duration}
+		if stored.Tags != nil {
+			cursor.Tags = *stored.Tags
+		}
+
+		cursors[group] = cursor
+	}
+
+	return cursors
+}
+
+// writeIniCursors returns the cursors given as a JSON object.
+func writeIniCursors(cursors map[string]Cursor) string {
+	stored := make(map[string]iniCursor, len(cursors))
+
+	for group, cursor := range cursors {
+		storedCursor := iniCursor{
+			File:      cursor.File,
+			ModTime:   formatIniTime(cursor.ModTime),
+			BirthTime: formatIniTime(cursor.BirthTime),
+			Size:      cursor.Size,
+		}
+
+		if cursor.Tags != (Tags{}) {
+			tags := cursor.Tags
+			storedCursor.Tags = &tags
+		}
+
+		if cursor.Duration > 0 {
+			storedCursor.Duration = cursor.Duration.String()
+		}
+
+		stored[group] = storedCursor
+	}
+
+	// Marshaling a map of plain values never fails
+	value, _ := json.Marshal(stored)
+
+	return string(value)
+}
+
+// formatIniTime returns the time given formatted as it is stored on the ini file.
+// If the time is zero, an empty string is returned.
+func formatIniTime(value time.Time) string {
+	if value.IsZero() {
+		return ""
+	}
+
+	return value.Format(time.RFC3339Nano)
+}
+
+// writeIniTime sets the time given on the key given of the ini section given.
+// If the time is zero, the key is removed.
+func writeIniTime(section *ini.Section, key string, value time.Time) {
+	if value.IsZero() {
+		section.DeleteKey(key)
+		return
+	}
+
+	section.Key(key).SetValue(formatIniTime(value))
+}
+
+// writeIniUint sets the number given on the key given of the ini section given.
+// If the number is zero, the key is removed.
+func writeIniUint(section *ini.Section, key string, value uint64) {
+	if value == 0 {
+		section.DeleteKey(key)
+		return
+	}
+
+	section.Key(key).SetValue(strconv.FormatUint(value, 10))
+}
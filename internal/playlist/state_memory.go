@@ -0,0 +1,51 @@
+package playlist
+
+import (
+	"sync"
+)
+
+// MemoryStateStore stores the resume state in memory.
+// It is useful for tests or for processes that do not need to resume after a restart.
+type MemoryStateStore struct {
+	mu     sync.Mutex
+	states map[string]State
+}
+
+// NewMemoryStateStore returns an empty in memory state store.
+func NewMemoryStateStore() *MemoryStateStore {
+	return &MemoryStateStore{states: map[string]State{}}
+}
+
+// Load returns the state saved for the key given.
+func (s *MemoryStateStore) Load(key string) (State, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.states[key].clone(), nil
+}
+
+// Save stores the state given for the key given.
+func (s *MemoryStateStore) Save(key string, state State) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.states[key] = state.clone()
+
+	return nil
+}
+
+// Update calls the update function given with the state of the key given and saves the result
+// holding the store lock.
+func (s *MemoryStateStore) Update(key string, update func(state *State) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state := s.states[key].clone()
+	if err := update(&state); err != nil {
+		return err
+	}
+
+	s.states[key] = state.clone()
+
+	return nil
+}
@@ -0,0 +1,71 @@
+package playlist
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+const (
+	_stateDirectoryName = "goplaylist"
+	_stateDirectoryPerm = 0o700
+)
+
+// LegacyStateFileName is the state file name of the current working directory used by previous versions.
+const LegacyStateFileName = _iniFileName
+
+// DefaultStateFileName returns the state file name following the XDG base directory specification.
+// It is placed under $XDG_STATE_HOME/goplaylist and, when the variable is empty or not absolute,
+// it falls back to ~/.local/state/goplaylist.
+func DefaultStateFileName() (string, error) {
+	stateHome := os.Getenv("XDG_STATE_HOME")
+	if stateHome == "" || !filepath.IsAbs(stateHome) {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+
+		stateHome = filepath.Join(home, ".local", "state")
+	}
+
+	return filepath.Join(stateHome, _stateDirectoryName, _iniFileName), nil
+}
+
+// MigrateLegacyStateFile moves the cfg.ini file of the current working directory used by previous versions
+// to the state file name given. The migration only happens when the state file name given doesn't exist yet.
+// It returns whether the legacy state file was migrated.
+func MigrateLegacyStateFile(fileName string) (bool, error) {
+	return migrateStateFile(fileName, _iniFileName)
+}
+
+// migrateStateFile moves the legacy state file name given to the state file name given
+// when the latter doesn't exist yet. It returns whether the legacy state file was migrated.
+func migrateStateFile(fileName, legacyFileName string) (bool, error) {
+	if _, err := os.Stat(fileName); err == nil || !os.IsNotExist(err) {
+		return false, err
+	}
+
+	content, err := ioutil.ReadFile(legacyFileName)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(fileName), _stateDirectoryPerm); err != nil {
+		return false, err
+	}
+
+	// The content is copied instead of renamed since the state directory could be on another device
+	if err := ioutil.WriteFile(fileName, content, 0o600); err != nil {
+		return false, err
+	}
+
+	if err := os.Remove(legacyFileName); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
@@ -0,0 +1,322 @@
+package playlist_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/masch/goplaylist/internal/playlist"
+)
+
+func TestIniStateStore(t *testing.T) {
+	directory, err := ioutil.TempDir("", "goplaylist")
+	require.NoError(t, err)
+
+	defer func() {
+		require.NoError(t, os.RemoveAll(directory))
+	}()
+
+	store := playlist.NewIniStateStore(filepath.Join(directory, "state.ini"))
+
+	got, err := store.Load("path_1")
+	require.NoError(t, err)
+	require.EqualValues(t, playlist.State{}, got)
+
+	lastModTime := time.Date(2021, time.March, 4, 10, 20, 30, 400, time.UTC)
+
+	require.NoError(t, store.Save("path_1", playlist.State{Last: "path_1/file_1.ext"}))
+	require.NoError(t, store.Save("path_2", playlist.State{Last: "path_2/file_1.ext"}))
+	require.NoError(t, store.Save("path_1", playlist.State{Last: "path_1/file_2.ext", LastModTime: lastModTime}))
+
+	got, err = playlist.NewIniStateStore(filepath.Join(directory, "state.ini")).Load("path_1")
+	require.NoError(t, err)
+	require.EqualValues(t, "path_1/file_2.ext", got.Last)
+	require.True(t, lastModTime.Equal(got.LastModTime))
+
+	got, err = store.Load("path_2")
+	require.NoError(t, err)
+	require.EqualValues(t, playlist.State{Last: "path_2/file_1.ext"}, got)
+
+	// Pending file names can contain any character
+	pending := []string{"path_3/file \"1\", `2`.ext", "path_3/file;3#4=5.ext", "path_3/fïlé_6\n.ext"}
+	require.NoError(t, store.Save("path_3", playlist.State{Pending: pending, PendingSince: lastModTime}))
+
+	got, err = store.Load("path_3")
+	require.NoError(t, err)
+	require.EqualValues(t, pending, got.Pending)
+	require.True(t, lastModTime.Equal(got.PendingSince))
+
+	shuffled := playlist.State{
+		Last:            "path_4/file_1.ext",
+		LastTags:        playlist.Tags{AlbumArtist: "Artist \"A\"", Album: "Album; 1", Disc: 1, Track: 2, Title: "#1"},
+		LastSize:        1 << 40,
+		LastDuration:    26122448979 * time.Nanosecond,
+		ShuffleSeed:     1<<64 - 1,
+		NextShuffleSeed: 1,
+		ResumeHints:     playlist.ResumeHintTags | playlist.ResumeHintSize | playlist.ResumeHintDuration,
+	}
+	require.NoError(t, store.Save("path_4", shuffled))
+
+	got, err = store.Load("path_4")
+	require.NoError(t, err)
+	require.EqualValues(t, shuffled, got)
+
+	cursors := map[string]playlist.Cursor{
+		"dir_1": {File: "path_5/dir_1/file_1.ext", ModTime: lastModTime, BirthTime: lastModTime.Add(-time.Hour)},
+		"dir_2": {
+			File: "path_5/dir_2/file_1.ext", Tags: playlist.Tags{Album: "Album", Track: 1}, Size: 5,
+			Duration: 90 * time.Second,
+		},
+		".": {File: "path_5/file_1.ext"},
+	}
+	require.NoError(t, store.Save("path_5", playlist.State{Last: "path_5/file_1.ext", Cursors: cursors}))
+
+	got, err = store.Load("path_5")
+	require.NoError(t, err)
+	require.EqualValues(t, playlist.State{Last: "path_5/file_1.ext", Cursors: cursors}, got)
+
+	require.NoError(t, store.Save("path_6", playlist.State{Cursors: map[string]playlist.Cursor{}}))
+
+	got, err = store.Load("path_6")
+	require.NoError(t, err)
+	require.EqualValues(t, playlist.State{Cursors: map[string]playlist.Cursor{}}, got)
+}
+
+func TestIniStateStoreLegacyCursors(t *testing.T) {
+	directory, clearFunc := createTemporaryDirectory(t)
+	defer clearFunc()
+
+	// The cursors saved by previous versions are only file names
+	fileName := filepath.Join(directory, "state.ini")
+	content := "[path_1]\nlast = path_1/dir_1/file_1.ext\n" +
+		"cursors = `{\"dir_1\":\"path_1/dir_1/file_1.ext\"}`\n"
+	require.NoError(t, ioutil.WriteFile(fileName, []byte(content), 0o600))
+
+	got, err := playlist.NewIniStateStore(fileName).Load("path_1")
+	require.NoError(t, err)
+	require.EqualValues(t, map[string]playlist.Cursor{"dir_1": {File: "path_1/dir_1/file_1.ext"}}, got.Cursors)
+}
+
+func TestMemoryStateStore(t *testing.T) {
+	store := playlist.NewMemoryStateStore()
+
+	got, err := store.Load("path_1")
+	require.NoError(t, err)
+	require.EqualValues(t, playlist.State{}, got)
+
+	require.NoError(t, store.Save("path_1", playlist.State{Last: "path_1/file_1.ext"}))
+
+	got, err = store.Load("path_1")
+	require.NoError(t, err)
+	require.EqualValues(t, playlist.State{Last: "path_1/file_1.ext"}, got)
+}
+
+func TestPlaylistWithMemoryStateStore(t *testing.T) {
+	store := playlist.NewMemoryStateStore()
+	client := playlist.Playlist{Store: store}
+
+	got, err := client.GetNextFilesFromPath("testdata/example_1", 5, []string{".ext"}, playlist.FileSortModeFileNameAsc)
+	require.NoError(t, err)
+	require.EqualValues(t, []string{
+		"testdata/example_1/dir_1/file_1_1.ext",
+		"testdata/example_1/dir_1/file_1_2.ext",
+		"testdata/example_1/dir_1/file_1_3.ext",
+		"testdata/example_1/dir_2/file_2_1.ext",
+		"testdata/example_1/dir_2/file_2_2.ext",
+	}, got)
+
+	state, err := store.Load("testdata/example_1")
+	require.NoError(t, err)
+	require.EqualValues(t, "testdata/example_1/dir_2/file_2_2.ext", state.Last)
+
+	got, err = client.GetNextFilesFromPath("testdata/example_1", 5, []string{".ext"}, playlist.FileSortModeFileNameAsc)
+	require.NoError(t, err)
+	require.EqualValues(t, []string{
+		"testdata/example_1/dir_3/file_3_1.ext",
+		"testdata/example_1/dir_3/file_3_2.ext",
+	}, got)
+
+	_, err = os.Stat("cfg.ini")
+	require.True(t, os.IsNotExist(err))
+}
+
+func TestDefaultStateFileName(t *testing.T) {
+	originalStateHome, stateHomeFound := os.LookupEnv("XDG_STATE_HOME")
+
+	defer func() {
+		if stateHomeFound {
+			require.NoError(t, os.Setenv("XDG_STATE_HOME", originalStateHome))
+		} else {
+			require.NoError(t, os.Unsetenv("XDG_STATE_HOME"))
+		}
+	}()
+
+	require.NoError(t, os.Setenv("XDG_STATE_HOME", "/state_home"))
+
+	got, err := playlist.DefaultStateFileName()
+	require.NoError(t, err)
+	require.EqualValues(t, "/state_home/goplaylist/cfg.ini", got)
+
+	require.NoError(t, os.Setenv("XDG_STATE_HOME", "relative_state_home"))
+
+	home, err := os.UserHomeDir()
+	require.NoError(t, err)
+
+	got, err = playlist.DefaultStateFileName()
+	require.NoError(t, err)
+	require.EqualValues(t, filepath.Join(home, ".local/state/goplaylist/cfg.ini"), got)
+}
+
+func TestMigrateLegacyStateFile(t *testing.T) {
+	// Ensure there is no ini configuration on the bootstrap and when the test finish
+	// If the file doesn't exist, the error is ignored
+	_ = os.Remove("cfg.ini")
+
+	defer func() {
+		_ = os.Remove("cfg.ini")
+		_ = os.Remove("cfg.ini.lock")
+	}()
+
+	directory, err := ioutil.TempDir("", "goplaylist")
+	require.NoError(t, err)
+
+	defer func() {
+		require.NoError(t, os.RemoveAll(directory))
+	}()
+
+	stateFileName := filepath.Join(directory, "goplaylist", "cfg.ini")
+
+	migrated, err := playlist.MigrateLegacyStateFile(stateFileName)
+	require.NoError(t, err)
+	require.False(t, migrated)
+
+	require.NoError(t, playlist.NewIniStateStore("cfg.ini").Save("path_1", playlist.State{Last: "file_1.ext"}))
+
+	migrated, err = playlist.MigrateLegacyStateFile(stateFileName)
+	require.NoError(t, err)
+	require.True(t, migrated)
+
+	_, err = os.Stat("cfg.ini")
+	require.True(t, os.IsNotExist(err))
+
+	got, err := playlist.NewIniStateStore(stateFileName).Load("path_1")
+	require.NoError(t, err)
+	require.EqualValues(t, playlist.State{Last: "file_1.ext"}, got)
+
+	// The state file already exists, so the legacy one is not migrated again
+	require.NoError(t, playlist.NewIniStateStore("cfg.ini").Save("path_1", playlist.State{Last: "file_2.ext"}))
+
+	migrated, err = playlist.MigrateLegacyStateFile(stateFileName)
+	require.NoError(t, err)
+	require.False(t, migrated)
+}
+
+func TestMigratingIniStateStore(t *testing.T) {
+	directory, err := ioutil.TempDir("", "goplaylist")
+	require.NoError(t, err)
+
+	defer func() {
+		require.NoError(t, os.RemoveAll(directory))
+	}()
+
+	legacyFileName := filepath.Join(directory, "cfg.ini")
+	stateFileName := filepath.Join(directory, "goplaylist", "cfg.ini")
+	store := playlist.NewMigratingIniStateStore(stateFileName, legacyFileName, nil)
+
+	require.NoError(t, playlist.NewIniStateStore(legacyFileName).Save("path_1", playlist.State{Last: "file_1.ext"}))
+
+	// Loading reads the legacy state file without moving it
+	got, err := store.Load("path_1")
+	require.NoError(t, err)
+	require.EqualValues(t, playlist.State{Last: "file_1.ext"}, got)
+
+	_, err = os.Stat(stateFileName)
+	require.True(t, os.IsNotExist(err))
+
+	// Updating moves the legacy state file before updating it
+	require.NoError(t, store.Update("path_2", func(state *playlist.State) error {
+		state.Last = "file_2.ext"
+		return nil
+	}))
+
+	_, err = os.Stat(legacyFileName)
+	require.True(t, os.IsNotExist(err))
+
+	for key, expected := range map[string]string{"path_1": "file_1.ext", "path_2": "file_2.ext"} {
+		got, err = playlist.NewIniStateStore(stateFileName).Load(key)
+		require.NoError(t, err)
+		require.EqualValues(t, playlist.State{Last: expected}, got)
+	}
+}
+
+func TestIniStateStoreConcurrentPlaylists(t *testing.T) {
+	const goroutines = 16
+
+	directory, err := ioutil.TempDir("", "goplaylist")
+	require.NoError(t, err)
+
+	defer func() {
+		require.NoError(t, os.RemoveAll(directory))
+	}()
+
+	stateFileName := filepath.Join(directory, "state.ini")
+	extensions := []string{".ext", ".ext2"}
+
+	expected, err := playlist.ListFilesByFileNamePath("testdata/example_1", extensions)
+	require.NoError(t, err)
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		handled = map[string]int{}
+		errs    []error
+	)
+
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			// Every goroutine uses its own store as different processes would do
+			client := playlist.Playlist{Store: playlist.NewIniStateStore(stateFileName)}
+
+			for {
+				got, err := client.GetNextFilesFromPath("testdata/example_1", 1, extensions, playlist.FileSortModeFileNameAsc)
+
+				mu.Lock()
+				if err != nil {
+					errs = append(errs, err)
+				}
+
+				for _, file := range got {
+					handled[file]++
+				}
+				mu.Unlock()
+
+				if err != nil || len(got) == 0 {
+					return
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	require.Empty(t, errs)
+	require.Len(t, handled, len(expected))
+
+	for _, file := range expected {
+		require.EqualValues(t, 1, handled[file], file)
+	}
+
+	// The temporary files used to write the state atomically are never left behind
+	files, err := filepath.Glob(filepath.Join(directory, "*.tmp"))
+	require.NoError(t, err)
+	require.Empty(t, files)
+}
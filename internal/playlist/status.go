@@ -0,0 +1,69 @@
+package playlist
+
+// Status represents the progress through the file list of a path.
+type Status struct {
+	// Last is the last file name processed. It is empty when no file was processed yet.
+	Last string `json:"last"`
+
+	// Index is the position of the last file name processed on the file list starting from 1.
+	// It is zero when no file was processed yet. If the last file doesn't exist anymore,
+	// it is the position of the file previous to where it would have been sorted.
+	Index int `json:"index"`
+
+	// Total is the count of files on the file list.
+	Total int `json:"total"`
+
+	// Remaining is the count of files after the last file name processed.
+	Remaining int `json:"remaining"`
+
+	// Pending is the count of files reserved which were not acknowledged yet.
+	Pending int `json:"pending"`
+
+	// Progress is the percent of files processed.
+	Progress float64 `json:"progress"`
+}
+
+// StatusFromPath returns the progress through the file list of the path given listed by the sort mode given
+// and filtered by the extensions given. The state is not modified.
+func (p *Playlist) StatusFromPath(path string, fileExtension []string, sortMode SortOrder) (Status, error) {
+	fileList, state, err := p.listFilesWithState(path, fileExtension, sortMode)
+	if err != nil {
+		return Status{}, err
+	}
+
+	status := Status{
+		Last:    state.Last,
+		Index:   listedPosition(path, fileList, state, sortMode) + 1,
+		Total:   len(fileList),
+		Pending: len(state.Pending),
+	}
+
+	status.Remaining = status.Total - status.Index
+
+	if status.Total > 0 {
+		status.Progress = float64(status.Index) * 100 / float64(status.Total)
+	}
+
+	return status, nil
+}
+
+// HistoryFromPath returns the file names of the path given listed by the sort mode given and filtered
+// by the extensions given which were already processed, that is, every file until the last file name processed.
+// The state is not modified.
+func (p *Playlist) HistoryFromPath(path string, fileExtension []string, sortMode SortOrder) ([]string, error) {
+	fileList, state, err := p.listFilesWithState(path, fileExtension, sortMode)
+	if err != nil {
+		return nil, err
+	}
+
+	if isInterleaved(sortMode) {
+		return interleavedHistory(path, fileList, state, sortMode), nil
+	}
+
+	position := lastFilePosition(fileList, state, sortMode)
+	if position < 0 {
+		return nil, nil
+	}
+
+	return fileList[:position+1], nil
+}
@@ -0,0 +1,76 @@
+package playlist_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/masch/goplaylist/internal/playlist"
+)
+
+func TestPlaylistStatusFromPath(t *testing.T) {
+	store := playlist.NewMemoryStateStore()
+	client := playlist.Playlist{Store: store}
+	extensions := []string{".ext", ".ext2"}
+
+	got, err := client.StatusFromPath("testdata/example_1", extensions, playlist.FileSortModeFileNameAsc)
+	require.NoError(t, err)
+	require.EqualValues(t, playlist.Status{Total: 8, Remaining: 8}, got)
+
+	_, err = client.GetNextFilesFromPath("testdata/example_1", 2, extensions, playlist.FileSortModeFileNameAsc)
+	require.NoError(t, err)
+
+	_, err = client.ReserveNextFilesFromPath("testdata/example_1", 1, extensions, playlist.FileSortModeFileNameAsc)
+	require.NoError(t, err)
+
+	got, err = client.StatusFromPath("testdata/example_1", extensions, playlist.FileSortModeFileNameAsc)
+	require.NoError(t, err)
+	require.EqualValues(t, playlist.Status{
+		Last:      "testdata/example_1/dir_1/file_1_2.ext",
+		Index:     2,
+		Total:     8,
+		Remaining: 6,
+		Pending:   1,
+		Progress:  25,
+	}, got)
+
+	// The status doesn't modify the state
+	state, err := store.Load("testdata/example_1")
+	require.NoError(t, err)
+	require.EqualValues(t, "testdata/example_1/dir_1/file_1_2.ext", state.Last)
+	require.Len(t, state.Pending, 1)
+
+	got, err = client.StatusFromPath("testdata/example_1", []string{".ext3"}, playlist.FileSortModeFileNameAsc)
+	require.NoError(t, err)
+	require.EqualValues(t, playlist.Status{Last: "testdata/example_1/dir_1/file_1_2.ext", Pending: 1}, got)
+
+	_, err = client.StatusFromPath("testdata/example_1", extensions, playlist.FileSortMode(100000))
+	require.True(t, errors.Is(err, playlist.ErrUnsupportedFileSortMode))
+}
+
+func TestPlaylistHistoryAndListFilesFromPath(t *testing.T) {
+	client := playlist.Playlist{Store: playlist.NewMemoryStateStore()}
+	extensions := []string{".ext"}
+
+	got, err := client.HistoryFromPath("testdata/example_1", extensions, playlist.FileSortModeFileNameAsc)
+	require.NoError(t, err)
+	require.Empty(t, got)
+
+	_, err = client.GetNextFilesFromPath("testdata/example_1", 2, extensions, playlist.FileSortModeFileNameAsc)
+	require.NoError(t, err)
+
+	got, err = client.HistoryFromPath("testdata/example_1", extensions, playlist.FileSortModeFileNameAsc)
+	require.NoError(t, err)
+	require.EqualValues(t, []string{
+		"testdata/example_1/dir_1/file_1_1.ext",
+		"testdata/example_1/dir_1/file_1_2.ext",
+	}, got)
+
+	got, err = client.ListFilesFromPath("testdata/example_1", extensions, playlist.FileSortModeFileNameAsc)
+	require.NoError(t, err)
+	require.Len(t, got, 7)
+
+	_, err = client.HistoryFromPath("testdata/example_1", extensions, playlist.FileSortMode(100000))
+	require.True(t, errors.Is(err, playlist.ErrUnsupportedFileSortMode))
+}
@@ -0,0 +1,142 @@
+package playlist
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// _tagsMagicLength is the count of bytes read from the start of a file in order to detect its format.
+const _tagsMagicLength = 12
+
+var (
+	// ErrTagsNotFound represent the error when a file has no tags or its format is not supported.
+	ErrTagsNotFound = fmt.Errorf("tags not found")
+)
+
+// Tags represents the tags of an audio or video file which define its position on an album.
+type Tags struct {
+	// AlbumArtist is the artist of the album. It is empty when the file has no album artist tag.
+	AlbumArtist string `json:"album_artist,omitempty"`
+
+	// Artist is the artist of the file.
+	Artist string `json:"artist,omitempty"`
+
+	// Album is the album name.
+	Album string `json:"album,omitempty"`
+
+	// Disc is the disc number on the album. It is zero when the file has no disc number tag.
+	Disc int `json:"disc,omitempty"`
+
+	// Track is the track number on the disc. It is zero when the file has no track number tag.
+	Track int `json:"track,omitempty"`
+
+	// Title is the title of the file.
+	Title string `json:"title,omitempty"`
+}
+
+// albumArtist returns the album artist or the artist when there is no album artist.
+func (t Tags) albumArtist() string {
+	if t.AlbumArtist != "" {
+		return t.AlbumArtist
+	}
+
+	return t.Artist
+}
+
+// ReadTags returns the tags of the file path given. The ID3v2 tags of MP3 files, the Vorbis comments
+// of FLAC, Ogg Vorbis and Opus files and the iTunes metadata atoms of MP4 files are supported, detecting
+// the format by the file content. If the file has none of them, ErrTagsNotFound is returned.
+func ReadTags(filePath string) (Tags, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return Tags{}, err
+	}
+	defer file.Close()
+
+	magic := make([]byte, _tagsMagicLength)
+	if _, err := io.ReadFull(file, magic); err != nil {
+		return Tags{}, fmt.Errorf("%w: %s", ErrTagsNotFound, filePath)
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return Tags{}, err
+	}
+
+	var tags Tags
+
+	switch {
+	case bytes.HasPrefix(magic, []byte("ID3")):
+		tags, err = readID3v2Tags(file)
+	case bytes.HasPrefix(magic, []byte("fLaC")):
+		tags, err = readFLACTags(file)
+	case bytes.HasPrefix(magic, []byte("OggS")):
+		tags, err = readOggTags(file)
+	case bytes.Equal(magic[4:8], []byte("ftyp")):
+		tags, err = readMP4Tags(file)
+	default:
+		return Tags{}, fmt.Errorf("%w: %s", ErrTagsNotFound, filePath)
+	}
+
+	if err != nil {
+		return Tags{}, fmt.Errorf("%w: %s: %s", ErrTagsNotFound, filePath, err)
+	}
+
+	if tags == (Tags{}) {
+		return Tags{}, fmt.Errorf("%w: %s", ErrTagsNotFound, filePath)
+	}
+
+	return tags, nil
+}
+
+// compareTags compares the files given by album artist, album, disc number, track number and title.
+// The files with tags are sorted before the files without them, which are compared by file name.
+func compareTags(a, b *sortFile) int {
+	tagsA, okA := a.getTags()
+	tagsB, okB := b.getTags()
+
+	switch {
+	case okA && !okB:
+		return -1
+	case !okA && okB:
+		return 1
+	case !okA && !okB:
+		return strings.Compare(a.path, b.path)
+	}
+
+	if result := compareNatural(tagsA.albumArtist(), tagsB.albumArtist()); result != 0 {
+		return result
+	}
+
+	if result := compareNatural(tagsA.Album, tagsB.Album); result != 0 {
+		return result
+	}
+
+	if tagsA.Disc != tagsB.Disc {
+		return tagsA.Disc - tagsB.Disc
+	}
+
+	if tagsA.Track != tagsB.Track {
+		return tagsA.Track - tagsB.Track
+	}
+
+	return compareNatural(tagsA.Title, tagsB.Title)
+}
+
+// parseTagNumber returns the number of a position tag value such as "3" or "3/12".
+// If the value is not a number, zero is returned.
+func parseTagNumber(value string) int {
+	if separator := strings.IndexByte(value, '/'); separator >= 0 {
+		value = value[:separator]
+	}
+
+	number, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || number < 0 {
+		return 0
+	}
+
+	return number
+}
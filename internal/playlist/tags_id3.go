@@ -0,0 +1,259 @@
+package playlist
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"unicode/utf16"
+)
+
+const (
+	_id3HeaderLength          = 10
+	_id3FrameHeaderLength     = 10
+	_id3v22FrameHeaderLength  = 6
+	_id3FlagUnsynchronisation = 0x80
+	_id3FlagExtendedHeader    = 0x40
+
+	// The frame format flags of the version 2.3
+	_id3v23FrameFlagCompression = 0x80
+	_id3v23FrameFlagEncryption  = 0x40
+
+	// The frame format flags of the version 2.4
+	_id3FrameFlagCompression    = 0x08
+	_id3FrameFlagEncryption     = 0x04
+	_id3FrameFlagUnsynchronised = 0x02
+	_id3FrameFlagDataLength     = 0x01
+
+	_id3TextEncodingUTF16   = 1
+	_id3TextEncodingUTF16BE = 2
+	_id3TextEncodingUTF8    = 3
+
+	_id3FrameIDAlbumArtist    = "TPE2"
+	_id3FrameIDArtist         = "TPE1"
+	_id3FrameIDAlbum          = "TALB"
+	_id3FrameIDDisc           = "TPOS"
+	_id3FrameIDTrack          = "TRCK"
+	_id3FrameIDTitle          = "TIT2"
+	_id3v22FrameIDAlbumArtist = "TP2"
+	_id3v22FrameIDArtist      = "TP1"
+	_id3v22FrameIDAlbum       = "TAL"
+	_id3v22FrameIDDisc        = "TPA"
+	_id3v22FrameIDTrack       = "TRK"
+	_id3v22FrameIDTitle       = "TT2"
+)
+
+// readID3v2Tags returns the tags of the ID3v2 tag placed at the start of the reader given.
+// The versions 2.2, 2.3 and 2.4 are supported.
+func readID3v2Tags(r io.Reader) (Tags, error) {
+	header := make([]byte, _id3HeaderLength)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return Tags{}, err
+	}
+
+	version, flags := header[3], header[5]
+	if version < 2 || version > 4 {
+		return Tags{}, fmt.Errorf("unsupported ID3v2 version 2.%d", version)
+	}
+
+	// The tag length is not trusted, a truncated or corrupted tag can claim up to 256 MB,
+	// so the tag is read growing the buffer up to the data available instead of allocating its length
+	length := int64(syncsafeInt(header[6:10]))
+
+	tag, err := ioutil.ReadAll(io.LimitReader(r, length))
+	if err != nil {
+		return Tags{}, err
+	}
+
+	if int64(len(tag)) < length {
+		return Tags{}, fmt.Errorf("ID3v2 tag length %d exceeds the file: %w", length, io.ErrUnexpectedEOF)
+	}
+
+	// The versions previous to 2.4 unsynchronise the whole tag
+	if flags&_id3FlagUnsynchronisation != 0 && version < 4 {
+		tag = removeUnsynchronisation(tag)
+	}
+
+	if flags&_id3FlagExtendedHeader != 0 && version > 2 {
+		tag = skipID3ExtendedHeader(tag, version)
+	}
+
+	var tags Tags
+
+	for len(tag) > 0 {
+		id, data, rest, ok := nextID3Frame(tag, version)
+		if !ok {
+			break
+		}
+
+		tag = rest
+
+		switch id {
+		case _id3FrameIDAlbumArtist, _id3v22FrameIDAlbumArtist:
+			tags.AlbumArtist = decodeID3Text(data)
+		case _id3FrameIDArtist, _id3v22FrameIDArtist:
+			tags.Artist = decodeID3Text(data)
+		case _id3FrameIDAlbum, _id3v22FrameIDAlbum:
+			tags.Album = decodeID3Text(data)
+		case _id3FrameIDDisc, _id3v22FrameIDDisc:
+			tags.Disc = parseTagNumber(decodeID3Text(data))
+		case _id3FrameIDTrack, _id3v22FrameIDTrack:
+			tags.Track = parseTagNumber(decodeID3Text(data))
+		case _id3FrameIDTitle, _id3v22FrameIDTitle:
+			tags.Title = decodeID3Text(data)
+		}
+	}
+
+	return tags, nil
+}
+
+// nextID3Frame returns the identifier and the data of the first frame of the tag given of the version given
+// and the rest of the tag after it. The frames which can't be read, such as the compressed or encrypted ones,
+// have no data. If there are no more frames, it returns false.
+func nextID3Frame(tag []byte, version byte) (id string, data, rest []byte, ok bool) {
+	headerLength := _id3FrameHeaderLength
+	if version == 2 {
+		headerLength = _id3v22FrameHeaderLength
+	}
+
+	// The padding after the last frame is filled with zeros
+	if len(tag) < headerLength || tag[0] == 0 {
+		return "", nil, nil, false
+	}
+
+	var size int
+
+	switch version {
+	case 2:
+		id, size = string(tag[:3]), int(tag[3])<<16|int(tag[4])<<8|int(tag[5])
+	case 3:
+		id, size = string(tag[:4]), int(binary.BigEndian.Uint32(tag[4:8]))
+	default:
+		id, size = string(tag[:4]), syncsafeInt(tag[4:8])
+	}
+
+	if size < 0 || size > len(tag)-headerLength {
+		return "", nil, nil, false
+	}
+
+	data, rest = tag[headerLength:headerLength+size], tag[headerLength+size:]
+
+	switch version {
+	case 3:
+		if flags := tag[9]; flags&(_id3v23FrameFlagCompression|_id3v23FrameFlagEncryption) != 0 {
+			return id, nil, rest, true
+		}
+	case 4:
+		flags := tag[9]
+		if flags&(_id3FrameFlagCompression|_id3FrameFlagEncryption) != 0 {
+			return id, nil, rest, true
+		}
+
+		if flags&_id3FrameFlagDataLength != 0 && len(data) >= 4 {
+			data = data[4:]
+		}
+
+		if flags&_id3FrameFlagUnsynchronised != 0 {
+			data = removeUnsynchronisation(data)
+		}
+	}
+
+	return id, data, rest, true
+}
+
+// skipID3ExtendedHeader returns the tag given of the version given without its extended header.
+func skipID3ExtendedHeader(tag []byte, version byte) []byte {
+	if len(tag) < 4 {
+		return nil
+	}
+
+	// The size of the version 2.3 doesn't include the size itself
+	size := syncsafeInt(tag[:4])
+	if version == 3 {
+		size = int(binary.BigEndian.Uint32(tag[:4])) + 4
+	}
+
+	if size < 0 || size > len(tag) {
+		return nil
+	}
+
+	return tag[size:]
+}
+
+// decodeID3Text returns the first string of the text frame data given decoded following its text encoding.
+func decodeID3Text(data []byte) string {
+	if len(data) == 0 {
+		return ""
+	}
+
+	encoding, text := data[0], data[1:]
+
+	switch encoding {
+	case _id3TextEncodingUTF16, _id3TextEncodingUTF16BE:
+		return decodeUTF16(text, encoding == _id3TextEncodingUTF16BE)
+	case _id3TextEncodingUTF8:
+		return string(trimAtByte(text, 0))
+	default:
+		// The ISO-8859-1 encoding is the default one
+		return decodeISO88591(trimAtByte(text, 0))
+	}
+}
+
+// decodeUTF16 returns the string of the UTF-16 text given until its first null character. The byte order is taken
+// from the byte order mark when it is present, otherwise it is big endian if it is told so and little endian if not.
+func decodeUTF16(text []byte, bigEndian bool) string {
+	var byteOrder binary.ByteOrder = binary.LittleEndian
+	if bigEndian {
+		byteOrder = binary.BigEndian
+	}
+
+	switch {
+	case bytes.HasPrefix(text, []byte{0xfe, 0xff}):
+		byteOrder, text = binary.BigEndian, text[2:]
+	case bytes.HasPrefix(text, []byte{0xff, 0xfe}):
+		byteOrder, text = binary.LittleEndian, text[2:]
+	}
+
+	var units []uint16
+
+	for i := 0; i+1 < len(text); i += 2 {
+		unit := byteOrder.Uint16(text[i:])
+		if unit == 0 {
+			break
+		}
+
+		units = append(units, unit)
+	}
+
+	return string(utf16.Decode(units))
+}
+
+// decodeISO88591 returns the string of the ISO-8859-1 text given.
+func decodeISO88591(text []byte) string {
+	runes := make([]rune, len(text))
+	for i, b := range text {
+		runes[i] = rune(b)
+	}
+
+	return string(runes)
+}
+
+// trimAtByte returns the data given until the first occurrence of the byte given.
+func trimAtByte(data []byte, b byte) []byte {
+	if end := bytes.IndexByte(data, b); end >= 0 {
+		return data[:end]
+	}
+
+	return data
+}
+
+// removeUnsynchronisation returns the data given without the zero bytes inserted after every 0xff byte.
+func removeUnsynchronisation(data []byte) []byte {
+	return bytes.ReplaceAll(data, []byte{0xff, 0x00}, []byte{0xff})
+}
+
+// syncsafeInt returns the value of the 4 bytes syncsafe integer given, which uses 7 bits of every byte.
+func syncsafeInt(b []byte) int {
+	return int(b[0]&0x7f)<<21 | int(b[1]&0x7f)<<14 | int(b[2]&0x7f)<<7 | int(b[3]&0x7f)
+}
@@ -0,0 +1,209 @@
+package playlist
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+const (
+	_mp4AtomHeaderLength      = 8
+	_mp4LargeSizeLength       = 8
+	_mp4FullAtomHeaderLength  = 4
+	_mp4DataAtomHeaderLength  = 8
+	_mp4MaxItemLength         = 1 << 20
+	_mp4AtomTypeMovie         = "moov"
+	_mp4AtomTypeUserData      = "udta"
+	_mp4AtomTypeMeta          = "meta"
+	_mp4AtomTypeItemList      = "ilst"
+	_mp4AtomTypeData          = "data"
+	_mp4ItemTypeAlbumArtist   = "aART"
+	_mp4ItemTypeArtist        = "\xa9ART"
+	_mp4ItemTypeAlbum         = "\xa9alb"
+	_mp4ItemTypeDisc          = "disk"
+	_mp4ItemTypeTrack         = "trkn"
+	_mp4ItemTypeTitle         = "\xa9nam"
+	_mp4PositionNumberOffset  = 2
+	_mp4PositionNumberLength  = 2
+	_mp4PositionMinDataLength = _mp4PositionNumberOffset + _mp4PositionNumberLength
+)
+
+// errMP4AtomNotFound represents the error when an atom is not found on a MP4 file.
+var errMP4AtomNotFound = errors.New("mp4 atom not found")
+
+// mp4Atom represents the position of an atom on a MP4 file.
+type mp4Atom struct {
+	atomType string
+
+	// offset is the position of the atom content after its header.
+	offset int64
+
+	// size is the size of the atom content without its header.
+	size int64
+}
+
+// readMP4Tags returns the tags of the iTunes metadata item list of the MP4 file given,
+// which is placed on the "moov.udta.meta.ilst" atom.
+func readMP4Tags(r io.ReadSeeker) (Tags, error) {
+	end, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return Tags{}, err
+	}
+
+	itemList, err := findMP4Atom(r, 0, end,
+		_mp4AtomTypeMovie, _mp4AtomTypeUserData, _mp4AtomTypeMeta, _mp4AtomTypeItemList)
+	if err != nil {
+		return Tags{}, err
+	}
+
+	items, err := readMP4Atoms(r, itemList.offset, itemList.offset+itemList.size)
+	if err != nil {
+		return Tags{}, err
+	}
+
+	var tags Tags
+
+	for _, item := range items {
+		switch item.atomType {
+		case _mp4ItemTypeAlbumArtist, _mp4ItemTypeArtist, _mp4ItemTypeAlbum, _mp4ItemTypeDisc, _mp4ItemTypeTrack,
+			_mp4ItemTypeTitle:
+		default:
+			continue
+		}
+
+		data, err := readMP4ItemData(r, item)
+		if err != nil {
+			continue
+		}
+
+		switch item.atomType {
+		case _mp4ItemTypeAlbumArtist:
+			tags.AlbumArtist = string(data)
+		case _mp4ItemTypeArtist:
+			tags.Artist = string(data)
+		case _mp4ItemTypeAlbum:
+			tags.Album = string(data)
+		case _mp4ItemTypeDisc:
+			tags.Disc = parseMP4Position(data)
+		case _mp4ItemTypeTrack:
+			tags.Track = parseMP4Position(data)
+		case _mp4ItemTypeTitle:
+			tags.Title = string(data)
+		}
+	}
+
+	return tags, nil
+}
+
+// findMP4Atom returns the atom found following the atom types path given from the atoms placed between
+// the start and end positions given. The "meta" atom is a full atom, so its version and flags are skipped.
+func findMP4Atom(r io.ReadSeeker, start, end int64, path ...string) (mp4Atom, error) {
+	atom := mp4Atom{offset: start, size: end - start}
+
+	for _, atomType := range path {
+		atoms, err := readMP4Atoms(r, atom.offset, atom.offset+atom.size)
+		if err != nil {
+			return mp4Atom{}, err
+		}
+
+		found := false
+
+		for _, child := range atoms {
+			if child.atomType == atomType {
+				atom, found = child, true
+				break
+			}
+		}
+
+		if !found {
+			return mp4Atom{}, errMP4AtomNotFound
+		}
+
+		if atomType == _mp4AtomTypeMeta && atom.size >= _mp4FullAtomHeaderLength {
+			atom.offset += _mp4FullAtomHeaderLength
+			atom.size -= _mp4FullAtomHeaderLength
+		}
+	}
+
+	return atom, nil
+}
+
+// readMP4Atoms returns the atoms placed between the start and end positions given.
+// The atom content is not read, so the media data atoms are skipped seeking after them.
+func readMP4Atoms(r io.ReadSeeker, start, end int64) ([]mp4Atom, error) {
+	var atoms []mp4Atom
+
+	for position := start; position+_mp4AtomHeaderLength <= end; {
+		if _, err := r.Seek(position, io.SeekStart); err != nil {
+			return nil, err
+		}
+
+		header := make([]byte, _mp4AtomHeaderLength)
+		if _, err := io.ReadFull(r, header); err != nil {
+			return nil, err
+		}
+
+		size, headerLength := int64(binary.BigEndian.Uint32(header)), int64(_mp4AtomHeaderLength)
+
+		switch size {
+		case 0:
+			// The atom extends to the end
+			size = end - position
+		case 1:
+			largeSize := make([]byte, _mp4LargeSizeLength)
+			if _, err := io.ReadFull(r, largeSize); err != nil {
+				return nil, err
+			}
+
+			size, headerLength = int64(binary.BigEndian.Uint64(largeSize)), headerLength+_mp4LargeSizeLength
+		}
+
+		if size < headerLength || position+size > end {
+			return nil, io.ErrUnexpectedEOF
+		}
+
+		atoms = append(atoms, mp4Atom{
+			atomType: string(header[4:8]),
+			offset:   position + headerLength,
+			size:     size - headerLength,
+		})
+
+		position += size
+	}
+
+	return atoms, nil
+}
+
+// readMP4ItemData returns the value of the "data" atom of the metadata item given.
+func readMP4ItemData(r io.ReadSeeker, item mp4Atom) ([]byte, error) {
+	data, err := findMP4Atom(r, item.offset, item.offset+item.size, _mp4AtomTypeData)
+	if err != nil {
+		return nil, err
+	}
+
+	// The data atom starts with its type and locale
+	if data.size < _mp4DataAtomHeaderLength || data.size > _mp4MaxItemLength {
+		return nil, errMP4AtomNotFound
+	}
+
+	if _, err := r.Seek(data.offset+_mp4DataAtomHeaderLength, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	value := make([]byte, data.size-_mp4DataAtomHeaderLength)
+	if _, err := io.ReadFull(r, value); err != nil {
+		return nil, err
+	}
+
+	return value, nil
+}
+
+// parseMP4Position returns the number of the disc or track item value given, which is made of
+// two reserved bytes, the number and the total as big endian 16 bits integers.
+func parseMP4Position(data []byte) int {
+	if len(data) < _mp4PositionMinDataLength {
+		return 0
+	}
+
+	return int(binary.BigEndian.Uint16(data[_mp4PositionNumberOffset:]))
+}
@@ -0,0 +1,177 @@
+package playlist_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/masch/goplaylist/internal/playlist"
+)
+
+func TestReadTags(t *testing.T) {
+	tt := []struct {
+		fileName string
+		expected playlist.Tags
+		err      error
+	}{
+		{
+			fileName: "testdata/tags/id3v22.mp3",
+			expected: playlist.Tags{AlbumArtist: "Artist A", Album: "Album 1", Disc: 1, Track: 1},
+		},
+		{
+			fileName: "testdata/tags/id3v23.mp3",
+			expected: playlist.Tags{Artist: "Artist B", Album: "Álbum 1", Track: 1, Title: "Title"},
+		},
+		{
+			fileName: "testdata/tags/id3v24.mp3",
+			expected: playlist.Tags{AlbumArtist: "Artist A", Artist: "Someone", Album: "Album 1", Disc: 1, Track: 2},
+		},
+		{
+			fileName: "testdata/tags/vorbis.flac",
+			expected: playlist.Tags{AlbumArtist: "Artist A", Artist: "Other", Album: "Album 1", Disc: 1, Track: 10},
+		},
+		{
+			fileName: "testdata/tags/vorbis.ogg",
+			expected: playlist.Tags{AlbumArtist: "Artist A", Album: "Album 2", Track: 1},
+		},
+		{
+			fileName: "testdata/tags/opus.opus",
+			expected: playlist.Tags{Artist: "Artist C", Album: "Album 1", Track: 3},
+		},
+		{
+			fileName: "testdata/tags/itunes.m4a",
+			expected: playlist.Tags{AlbumArtist: "Artist A", Album: "Album 1", Disc: 2, Track: 1, Title: "Title"},
+		},
+		{
+			fileName: "testdata/tags/untagged.mp3",
+			err:      playlist.ErrTagsNotFound,
+		},
+		{
+			fileName: "testdata/example_1/dir_1/file_1_1.ext",
+			err:      playlist.ErrTagsNotFound,
+		},
+		{
+			fileName: "testdata/tags/missing.mp3",
+			err:      os.ErrNotExist,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.fileName, func(t *testing.T) {
+			got, err := playlist.ReadTags(tc.fileName)
+			require.True(t, errors.Is(err, tc.err), err)
+			require.EqualValues(t, tc.expected, got)
+		})
+	}
+}
+
+func TestListFilesFromPathByTags(t *testing.T) {
+	client := playlist.Playlist{Store: playlist.NewMemoryStateStore()}
+
+	got, err := client.ListFilesFromPath("testdata/tags", []string{".mp3", ".flac", ".ogg", ".opus", ".m4a"},
+		playlist.FileSortModeTagsAsc)
+	require.NoError(t, err)
+	require.EqualValues(t, []string{
+		"testdata/tags/id3v22.mp3",
+		"testdata/tags/id3v24.mp3",
+		"testdata/tags/vorbis.flac",
+		"testdata/tags/itunes.m4a",
+		"testdata/tags/vorbis.ogg",
+		"testdata/tags/id3v23.mp3",
+		"testdata/tags/opus.opus",
+		"testdata/tags/untagged.mp3",
+	}, got)
+}
+
+func TestPlaylistResumeFromMissingLastFileByTags(t *testing.T) {
+	directory, clearFunc := createTemporaryDirectory(t)
+	defer clearFunc()
+
+	copyTestdataFiles(t, "testdata/tags", directory)
+
+	extensions := []string{".mp3", ".flac", ".ogg", ".opus", ".m4a"}
+	store := playlist.NewMemoryStateStore()
+	client := playlist.Playlist{Store: store}
+
+	got, err := client.GetNextFilesFromPath(directory, 3, extensions, playlist.FileSortModeTagsAsc)
+	require.NoError(t, err)
+	require.EqualValues(t, []string{
+		filepath.Join(directory, "id3v22.mp3"),
+		filepath.Join(directory, "id3v24.mp3"),
+		filepath.Join(directory, "vorbis.flac"),
+	}, got)
+
+	state, err := store.Load(directory)
+	require.NoError(t, err)
+	require.EqualValues(t, playlist.Tags{AlbumArtist: "Artist A", Artist: "Other", Album: "Album 1", Disc: 1, Track: 10},
+		state.LastTags)
+
+	// The last file is deleted, so it resumes after its tags
+	require.NoError(t, os.Remove(filepath.Join(directory, "vorbis.flac")))
+
+	got, err = client.GetNextFilesFromPath(directory, 1, extensions, playlist.FileSortModeTagsAsc)
+	require.NoError(t, err)
+	require.EqualValues(t, []string{filepath.Join(directory, "itunes.m4a")}, got)
+
+	// The acknowledgement saves the tags of the files reserved by the tags sort mode
+	got, err = client.ReserveNextFilesFromPath(directory, 1, extensions, playlist.FileSortModeTagsAsc)
+	require.NoError(t, err)
+	require.EqualValues(t, []string{filepath.Join(directory, "vorbis.ogg")}, got)
+
+	_, err = client.AckFilesFromPath(directory, nil)
+	require.NoError(t, err)
+
+	state, err = store.Load(directory)
+	require.NoError(t, err)
+	require.EqualValues(t, playlist.Tags{AlbumArtist: "Artist A", Album: "Album 2", Track: 1}, state.LastTags)
+
+	// The tags are not read when the sort order doesn't compare them
+	require.NoError(t, client.SeekFileFromPath(directory, filepath.Join(directory, "id3v24.mp3"), extensions,
+		playlist.FileSortModeFileNameAsc))
+
+	state, err = store.Load(directory)
+	require.NoError(t, err)
+	require.EqualValues(t, filepath.Join(directory, "id3v23.mp3"), state.Last)
+	require.Zero(t, state.LastTags)
+	require.Zero(t, state.ResumeHints)
+}
+
+func TestReadTagsTruncatedID3(t *testing.T) {
+	directory, clearFunc := createTemporaryDirectory(t)
+	defer clearFunc()
+
+	// The tag claims the greatest length, 256 MB, but the file ends after a single frame
+	fileName := filepath.Join(directory, "truncated.mp3")
+	content := append([]byte("ID3\x03\x00\x00\x7f\x7f\x7f\x7f"), "TALB\x00\x00\x00\x06\x00\x00\x00Album"...)
+	require.NoError(t, ioutil.WriteFile(fileName, content, 0o600))
+
+	_, err := playlist.ReadTags(fileName)
+	require.True(t, errors.Is(err, playlist.ErrTagsNotFound), err)
+	require.Contains(t, err.Error(), "exceeds the file")
+}
+
+// copyTestdataFiles copies the files of the source directory given to the directory given.
+func copyTestdataFiles(t *testing.T, source, directory string) {
+	t.Helper()
+
+	files, err := ioutil.ReadDir(source)
+	require.NoError(t, err)
+
+	for _, file := range files {
+		copyTestdataFile(t, filepath.Join(source, file.Name()), filepath.Join(directory, file.Name()))
+	}
+}
+
+// copyTestdataFile copies the source file given to the target file given.
+func copyTestdataFile(t *testing.T, source, target string) {
+	t.Helper()
+
+	content, err := ioutil.ReadFile(source)
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(target, content, 0o600))
+}
@@ -0,0 +1,241 @@
+package playlist
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"io/ioutil"
+	"strings"
+)
+
+const (
+	_flacMagicLength                   = 4
+	_flacBlockHeaderLength             = 4
+	_flacBlockLastFlag                 = 0x80
+	_flacBlockTypeMask                 = 0x7f
+	_flacBlockTypeVorbisComment        = 4
+	_oggPageHeaderLength               = 27
+	_oggMaxPackets                     = 3
+	_oggMaxPacketLength                = 16 << 20
+	_oggSegmentTableLengthOffset       = 26
+	_vorbisCommentHeaderType           = "\x03vorbis"
+	_opusCommentHeaderType             = "OpusTags"
+	_vorbisCommentKeyAlbumArtist       = "ALBUMARTIST"
+	_vorbisCommentKeyAlbumArtistSpaced = "ALBUM ARTIST"
+	_vorbisCommentKeyArtist            = "ARTIST"
+	_vorbisCommentKeyAlbum             = "ALBUM"
+	_vorbisCommentKeyDisc              = "DISCNUMBER"
+	_vorbisCommentKeyTrack             = "TRACKNUMBER"
+	_vorbisCommentKeyTitle             = "TITLE"
+)
+
+// errVorbisCommentNotFound represents the error when a stream has no Vorbis comment.
+var errVorbisCommentNotFound = errors.New("vorbis comment not found")
+
+// readFLACTags returns the tags of the Vorbis comment metadata block of the FLAC stream given.
+func readFLACTags(r io.Reader) (Tags, error) {
+	if _, err := io.ReadFull(r, make([]byte, _flacMagicLength)); err != nil {
+		return Tags{}, err
+	}
+
+	header := make([]byte, _flacBlockHeaderLength)
+
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			return Tags{}, err
+		}
+
+		blockType := header[0] & _flacBlockTypeMask
+		length := int64(header[1])<<16 | int64(header[2])<<8 | int64(header[3])
+
+		if blockType == _flacBlockTypeVorbisComment {
+			block := make([]byte, length)
+			if _, err := io.ReadFull(r, block); err != nil {
+				return Tags{}, err
+			}
+
+			return parseVorbisComment(block)
+		}
+
+		if header[0]&_flacBlockLastFlag != 0 {
+			return Tags{}, errVorbisCommentNotFound
+		}
+
+		if _, err := io.CopyN(ioutil.Discard, r, length); err != nil {
+			return Tags{}, err
+		}
+	}
+}
+
+// readOggTags returns the tags of the comment header packet of the first Ogg Vorbis or Opus stream given.
+// The comment header is the second packet of the stream.
+func readOggTags(r io.Reader) (Tags, error) {
+	packets := newOggPacketReader(r)
+
+	for i := 0; i < _oggMaxPackets; i++ {
+		packet, err := packets.next()
+		if err != nil {
+			return Tags{}, err
+		}
+
+		switch {
+		case bytes.HasPrefix(packet, []byte(_vorbisCommentHeaderType)):
+			return parseVorbisComment(packet[len(_vorbisCommentHeaderType):])
+		case bytes.HasPrefix(packet, []byte(_opusCommentHeaderType)):
+			return parseVorbisComment(packet[len(_opusCommentHeaderType):])
+		}
+	}
+
+	return Tags{}, errVorbisCommentNotFound
+}
+
+// parseVorbisComment returns the tags of the Vorbis comment given, which is made of a vendor string
+// and a list of "KEY=value" fields. The keys are case-insensitive.
+func parseVorbisComment(comment []byte) (Tags, error) {
+	vendor, comment, ok := readVorbisString(comment)
+	if !ok || vendor == nil || len(comment) < 4 {
+		return Tags{}, errVorbisCommentNotFound
+	}
+
+	count := binary.LittleEndian.Uint32(comment)
+	comment = comment[4:]
+
+	var tags Tags
+
+	for i := uint32(0); i < count; i++ {
+		var field []byte
+
+		field, comment, ok = readVorbisString(comment)
+		if !ok {
+			break
+		}
+
+		separator := bytes.IndexByte(field, '=')
+		if separator < 0 {
+			continue
+		}
+
+		key, value := strings.ToUpper(string(field[:separator])), string(field[separator+1:])
+
+		switch key {
+		case _vorbisCommentKeyAlbumArtist, _vorbisCommentKeyAlbumArtistSpaced:
+			tags.AlbumArtist = value
+		case _vorbisCommentKeyArtist:
+			tags.Artist = value
+		case _vorbisCommentKeyAlbum:
+			tags.Album = value
+		case _vorbisCommentKeyDisc:
+			tags.Disc = parseTagNumber(value)
+		case _vorbisCommentKeyTrack:
+			tags.Track = parseTagNumber(value)
+		case _vorbisCommentKeyTitle:
+			tags.Title = value
+		}
+	}
+
+	return tags, nil
+}
+
+// readVorbisString returns the string prefixed by its little endian 32 bits length at the start of the data given
+// and the rest of the data after it. If the data is too short, it returns false.
+func readVorbisString(data []byte) (value, rest []byte, ok bool) {
+	if len(data) < 4 {
+		return nil, nil, false
+	}
+
+	length := binary.LittleEndian.Uint32(data)
+	if uint64(length) > uint64(len(data)-4) {
+		return nil, nil, false
+	}
+
+	return data[4 : 4+length], data[4+length:], true
+}
+
+// oggPacketReader reads the packets of the first logical stream of an Ogg stream.
+// The packets are split into segments of up to 255 bytes which can span several pages.
+type oggPacketReader struct {
+	r        io.Reader
+	serial   uint32
+	started  bool
+	segments []byte
+	page     []byte
+}
+
+// newOggPacketReader returns the Ogg packet reader of the Ogg stream given.
+func newOggPacketReader(r io.Reader) *oggPacketReader {
+	return &oggPacketReader{r: r}
+}
+
+// next returns the next packet of the stream.
+func (o *oggPacketReader) next() ([]byte, error) {
+	var packet []byte
+
+	for {
+		// Read the next page when every segment of the current one was read
+		for len(o.segments) == 0 {
+			if err := o.readPage(); err != nil {
+				return nil, err
+			}
+		}
+
+		length := int(o.segments[0])
+		o.segments = o.segments[1:]
+
+		if length > len(o.page) {
+			return nil, io.ErrUnexpectedEOF
+		}
+
+		packet = append(packet, o.page[:length]...)
+		o.page = o.page[length:]
+
+		if len(packet) > _oggMaxPacketLength {
+			return nil, errVorbisCommentNotFound
+		}
+
+		// A segment shorter than 255 bytes ends the packet
+		if length < 255 {
+			return packet, nil
+		}
+	}
+}
+
+// readPage reads the next page of the first logical stream.
+func (o *oggPacketReader) readPage() error {
+	for {
+		header := make([]byte, _oggPageHeaderLength)
+		if _, err := io.ReadFull(o.r, header); err != nil {
+			return err
+		}
+
+		if !bytes.HasPrefix(header, []byte("OggS")) {
+			return errVorbisCommentNotFound
+		}
+
+		segments := make([]byte, header[_oggSegmentTableLengthOffset])
+		if _, err := io.ReadFull(o.r, segments); err != nil {
+			return err
+		}
+
+		length := 0
+		for _, segment := range segments {
+			length += int(segment)
+		}
+
+		page := make([]byte, length)
+		if _, err := io.ReadFull(o.r, page); err != nil {
+			return err
+		}
+
+		serial := binary.LittleEndian.Uint32(header[14:18])
+		if !o.started {
+			o.serial, o.started = serial, true
+		}
+
+		// The pages of the rest of the logical streams are skipped
+		if serial == o.serial {
+			o.segments, o.page = segments, page
+			return nil
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package playlist_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/masch/goplaylist/internal/playlist"
+)
+
+func TestListFilesByModTime(t *testing.T) {
+	directory, removeDirectory := createTemporaryDirectory(t)
+	defer removeDirectory()
+
+	baseTime := time.Date(2021, 3, 28, 1, 59, 59, 0, time.UTC)
+
+	// The file names are sorted on the opposite order of their modification time
+	files := []struct {
+		name    string
+		modTime time.Time
+	}{
+		{name: "d---first.ext", modTime: baseTime},
+		{name: "c.ext", modTime: baseTime.Add(250 * time.Millisecond)},
+		{name: "b---.ext", modTime: baseTime.Add(time.Second)},
+		{name: "a.ext", modTime: baseTime.In(time.FixedZone("UTC+14", 14*60*60)).Add(time.Hour)},
+		{name: "z_same_time.ext", modTime: baseTime.Add(2 * time.Hour)},
+		{name: "y_same_time.ext", modTime: baseTime.Add(2 * time.Hour)},
+	}
+
+	for _, file := range files {
+		createFileWithTimes(t, filepath.Join(directory, file.name), file.modTime)
+	}
+
+	expected := []string{
+		filepath.Join(directory, "d---first.ext"),
+		filepath.Join(directory, "c.ext"),
+		filepath.Join(directory, "b---.ext"),
+		filepath.Join(directory, "a.ext"),
+		// The files with the same time are sorted by name
+		filepath.Join(directory, "y_same_time.ext"),
+		filepath.Join(directory, "z_same_time.ext"),
+	}
+
+	got, err := playlist.ListFilesByDateCreation(directory, []string{".ext"})
+	require.NoError(t, err)
+	require.EqualValues(t, expected, got)
+
+	got, err = (&playlist.Playlist{}).ListFilesFromPath(directory, []string{".ext"}, playlist.FileSortModeModTimeAsc)
+	require.NoError(t, err)
+	require.EqualValues(t, expected, got)
+}
+
+func TestListFilesByBirthTime(t *testing.T) {
+	directory, removeDirectory := createTemporaryDirectory(t)
+	defer removeDirectory()
+
+	baseTime := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	fileNames := []string{"c.ext", "a.ext", "b.ext"}
+
+	// The files are created on order, but their modification times are set on the opposite order
+	for i, fileName := range fileNames {
+		createFileWithTimes(t, filepath.Join(directory, fileName), baseTime.Add(time.Duration(len(fileNames)-i)*time.Hour))
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	expected := []string{
+		filepath.Join(directory, "c.ext"),
+		filepath.Join(directory, "a.ext"),
+		filepath.Join(directory, "b.ext"),
+	}
+
+	if _, ok := playlist.FileBirthTime(expected[0]); !ok {
+		t.Log("the birth time is not available, the modification time is used")
+
+		expected[0], expected[2] = expected[2], expected[0]
+	}
+
+	got, err := playlist.ListFilesByBirthTime(directory, []string{".ext"})
+	require.NoError(t, err)
+	require.EqualValues(t, expected, got)
+
+	// The last file is deleted, so it resumes after its birth time
+	client := playlist.Playlist{Store: playlist.NewMemoryStateStore()}
+
+	got, err = client.GetNextFilesFromPath(directory, 2, []string{".ext"}, playlist.FileSortModeBirthTimeAsc)
+	require.NoError(t, err)
+	require.EqualValues(t, expected[:2], got)
+
+	require.NoError(t, os.Remove(expected[1]))
+
+	got, err = client.GetNextFilesFromPath(directory, 1, []string{".ext"}, playlist.FileSortModeBirthTimeAsc)
+	require.NoError(t, err)
+	require.EqualValues(t, expected[2:], got)
+}
+
+func TestFileBirthTime(t *testing.T) {
+	_, ok := playlist.FileBirthTime("testdata/missing.ext")
+	require.False(t, ok)
+}
+
+func createTemporaryDirectory(t *testing.T) (string, func()) {
+	t.Helper()
+
+	directory, err := ioutil.TempDir("", "goplaylist")
+	require.NoError(t, err)
+
+	return directory, func() {
+		require.NoError(t, os.RemoveAll(directory))
+	}
+}
+
+func createFileWithTimes(t *testing.T, fileName string, modTime time.Time) {
+	t.Helper()
+
+	require.NoError(t, ioutil.WriteFile(fileName, nil, 0o600))
+	require.NoError(t, os.Chtimes(fileName, modTime, modTime))
+}